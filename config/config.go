@@ -0,0 +1,634 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported log formats
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// Supported trace exporters
+const (
+	TraceExporterNone   = "none"
+	TraceExporterStdout = "stdout"
+	TraceExporterOTLP   = "otlp"
+)
+
+// Supported rate limiter backends
+const (
+	RateLimitMemory = "memory"
+	RateLimitRedis  = "redis"
+)
+
+// Supported database drivers
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	DB          DBConfig          `yaml:"db"`
+	Auth        AuthConfig        `yaml:"auth"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Security    SecurityConfig    `yaml:"security"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Wallet      WalletConfig      `yaml:"wallet"`
+	Fund        FundConfig        `yaml:"fund"`
+}
+
+type ServerConfig struct {
+	Port    uint64 `yaml:"port"`
+	GinMode string `yaml:"gin_mode"`
+	// GRPCPort serves the gRPC API for other backend services, 0 turns it off
+	GRPCPort uint64 `yaml:"grpc_port"`
+	// MetricsPort serves /metrics to Prometheus apart from the public API, 0 turns it off
+	MetricsPort uint64 `yaml:"metrics_port"`
+	// ShutdownTimeout is how long in-flight requests may take to finish after SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// TracingConfig selects where OpenTelemetry spans go. The OTLP exporter speaks
+// HTTP/protobuf, by default to a collector on localhost.
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter"`
+	ServiceName  string  `yaml:"service_name"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint"`
+	OTLPInsecure bool    `yaml:"otlp_insecure"`
+	SampleRatio  float64 `yaml:"sample_ratio"`
+}
+
+type DBConfig struct {
+	Driver     string          `yaml:"driver"`
+	MySQL      SQLServerConfig `yaml:"mysql"`
+	Postgres   SQLServerConfig `yaml:"postgres"`
+	SQLitePath string          `yaml:"sqlite_path"`
+	// SlowQuery is the duration above which a query is logged as a warning
+	SlowQuery time.Duration `yaml:"slow_query"`
+}
+
+type SQLServerConfig struct {
+	Host     string `yaml:"host"`
+	Port     uint64 `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"ssl_mode"`
+}
+
+type AuthConfig struct {
+	JWKSURL     string        `yaml:"jwks_url"`
+	JWKSFile    string        `yaml:"jwks_file"`
+	JWKSRefresh time.Duration `yaml:"jwks_refresh"`
+	Issuer      string        `yaml:"issuer"`
+	Audience    []string      `yaml:"audience"`
+	Leeway      time.Duration `yaml:"leeway"`
+}
+
+// CORSConfig lists the browser origins allowed to call the API. An origin may use
+// a wildcard for one or more subdomain levels, as in https://*.investio.dewkul.me
+type CORSConfig struct {
+	AllowOrigins     []string      `yaml:"allow_origins"`
+	AllowMethods     []string      `yaml:"allow_methods"`
+	AllowHeaders     []string      `yaml:"allow_headers"`
+	ExposeHeaders    []string      `yaml:"expose_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+// SecurityConfig controls the security response headers, usually enabled only
+// where the API is served over HTTPS
+type SecurityConfig struct {
+	Headers               bool          `yaml:"headers"`
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `yaml:"hsts_include_subdomains"`
+	FrameOptions          string        `yaml:"frame_options"`
+}
+
+// RateLimitConfig limits each user separately for reads and for order placement.
+// The redis backend shares the limits between replicas.
+type RateLimitConfig struct {
+	Backend string      `yaml:"backend"`
+	Read    LimitConfig `yaml:"read"`
+	Order   LimitConfig `yaml:"order"`
+}
+
+// LimitConfig is a token bucket: Burst requests at once, refilled at Rate per
+// second. A Rate of 0 turns the limit off.
+type LimitConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// IdempotencyConfig sets how long an order's Idempotency-Key and its response are kept
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl"`
+	// Lease is how long an unfinished request holds its key before a retry may run it again
+	Lease time.Duration `yaml:"lease"`
+}
+
+type WalletConfig struct {
+	StartBalance decimal.Decimal `yaml:"start_balance"`
+}
+
+type FundConfig struct {
+	SeedFile string `yaml:"seed_file"`
+	APIURL   string `yaml:"api_url"`
+	// RefreshInterval reloads the catalogue, and so its NAVs, while the server runs; 0 loads it once at startup
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+// Default returns the settings used when nothing else is configured
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            5005,
+			GinMode:         "debug",
+			GRPCPort:        5006,
+			MetricsPort:     9090,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogFormatJSON,
+		},
+		Tracing: TracingConfig{
+			Exporter:     TraceExporterNone,
+			ServiceName:  "sim-api",
+			OTLPEndpoint: "localhost:4318",
+			OTLPInsecure: true,
+			SampleRatio:  1,
+		},
+		DB: DBConfig{
+			Driver:     DriverMySQL,
+			MySQL:      SQLServerConfig{Port: 3306},
+			Postgres:   SQLServerConfig{Port: 5432, SSLMode: "disable"},
+			SQLitePath: "sim.db",
+			SlowQuery:  200 * time.Millisecond,
+		},
+		Auth: AuthConfig{
+			JWKSFile:    "./keys/jwks.json",
+			JWKSRefresh: 15 * time.Minute,
+			Leeway:      jwt.DefaultLeeway,
+		},
+		CORS: CORSConfig{
+			AllowOrigins:     []string{"http://localhost:2564", "https://investio.dewkul.me", "https://investio.netlify.app"},
+			AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+			AllowHeaders:     []string{"Authorization", "Content-Type", "X-Request-ID", "Idempotency-Key"},
+			ExposeHeaders:    []string{"X-Request-ID", "Idempotent-Replayed"},
+			AllowCredentials: true,
+			MaxAge:           12 * time.Hour,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:   180 * 24 * time.Hour,
+			FrameOptions: "DENY",
+		},
+		RateLimit: RateLimitConfig{
+			Backend: RateLimitMemory,
+			Read:    LimitConfig{Rate: 10, Burst: 20},
+			Order:   LimitConfig{Rate: 1, Burst: 5},
+		},
+		Redis: RedisConfig{
+			Addr: "localhost:6379",
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:   24 * time.Hour,
+			Lease: time.Minute,
+		},
+		Wallet: WalletConfig{
+			StartBalance: decimal.NewFromInt32(1000000),
+		},
+	}
+}
+
+// Load builds the configuration from, in increasing priority: defaults, the YAML file
+// named by CONFIG_FILE, a .env file (outside release mode) and the environment.
+// The result is validated before it is returned.
+func Load() (cfg Config, err error) {
+	cfg = Default()
+
+	if os.Getenv("GIN_MODE") != "release" {
+		// A missing .env file is fine, the environment may hold everything
+		_ = godotenv.Load()
+	}
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("config: parse %s: %w", path, err)
+		}
+	}
+
+	env := envReader{}
+	env.uint("API_PORT", &cfg.Server.Port)
+	env.string("GIN_MODE", &cfg.Server.GinMode)
+	env.uint("GRPC_PORT", &cfg.Server.GRPCPort)
+	env.uint("METRICS_PORT", &cfg.Server.MetricsPort)
+	env.duration("SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+
+	env.string("LOG_LEVEL", &cfg.Log.Level)
+	env.string("LOG_FORMAT", &cfg.Log.Format)
+
+	env.string("TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	env.string("OTEL_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	env.string("OTEL_EXPORTER_OTLP_ENDPOINT", &cfg.Tracing.OTLPEndpoint)
+	env.bool("OTEL_EXPORTER_OTLP_INSECURE", &cfg.Tracing.OTLPInsecure)
+	env.float("TRACING_SAMPLE_RATIO", &cfg.Tracing.SampleRatio)
+
+	env.string("DB_DRIVER", &cfg.DB.Driver)
+	env.duration("DB_SLOW_QUERY", &cfg.DB.SlowQuery)
+	env.sqlServer("MYSQL", &cfg.DB.MySQL)
+	env.sqlServer("POSTGRES", &cfg.DB.Postgres)
+	env.string("POSTGRES_SSLMODE", &cfg.DB.Postgres.SSLMode)
+	env.string("SQLITE_PATH", &cfg.DB.SQLitePath)
+
+	env.string("JWKS_URL", &cfg.Auth.JWKSURL)
+	env.string("JWKS_FILE", &cfg.Auth.JWKSFile)
+	env.duration("JWKS_REFRESH", &cfg.Auth.JWKSRefresh)
+	env.string("JWT_ISSUER", &cfg.Auth.Issuer)
+	env.list("JWT_AUDIENCE", &cfg.Auth.Audience)
+	env.duration("JWT_LEEWAY", &cfg.Auth.Leeway)
+
+	env.list("CORS_ALLOW_ORIGINS", &cfg.CORS.AllowOrigins)
+	env.list("CORS_ALLOW_METHODS", &cfg.CORS.AllowMethods)
+	env.list("CORS_ALLOW_HEADERS", &cfg.CORS.AllowHeaders)
+	env.list("CORS_EXPOSE_HEADERS", &cfg.CORS.ExposeHeaders)
+	env.bool("CORS_ALLOW_CREDENTIALS", &cfg.CORS.AllowCredentials)
+	env.duration("CORS_MAX_AGE", &cfg.CORS.MaxAge)
+
+	env.bool("SECURITY_HEADERS", &cfg.Security.Headers)
+	env.duration("SECURITY_HSTS_MAX_AGE", &cfg.Security.HSTSMaxAge)
+	env.bool("SECURITY_HSTS_INCLUDE_SUBDOMAINS", &cfg.Security.HSTSIncludeSubdomains)
+	env.string("SECURITY_FRAME_OPTIONS", &cfg.Security.FrameOptions)
+
+	env.string("RATE_LIMIT_BACKEND", &cfg.RateLimit.Backend)
+	env.float("RATE_LIMIT_READ_RATE", &cfg.RateLimit.Read.Rate)
+	env.int("RATE_LIMIT_READ_BURST", &cfg.RateLimit.Read.Burst)
+	env.float("RATE_LIMIT_ORDER_RATE", &cfg.RateLimit.Order.Rate)
+	env.int("RATE_LIMIT_ORDER_BURST", &cfg.RateLimit.Order.Burst)
+
+	env.string("REDIS_ADDR", &cfg.Redis.Addr)
+	env.string("REDIS_PASSWORD", &cfg.Redis.Password)
+	env.int("REDIS_DB", &cfg.Redis.DB)
+
+	env.duration("IDEMPOTENCY_TTL", &cfg.Idempotency.TTL)
+	env.duration("IDEMPOTENCY_LEASE", &cfg.Idempotency.Lease)
+
+	env.decimal("WALLET_START_BALANCE", &cfg.Wallet.StartBalance)
+
+	env.string("FUND_SEED_FILE", &cfg.Fund.SeedFile)
+	env.string("FUND_API_URL", &cfg.Fund.APIURL)
+	env.duration("FUND_REFRESH_INTERVAL", &cfg.Fund.RefreshInterval)
+
+	// Report unparsable values together with the rest of the invalid settings
+	problems := env.problems
+	if verr, ok := cfg.Validate().(*ValidationError); ok {
+		problems = append(problems, verr.Problems...)
+	}
+	if len(problems) > 0 {
+		err = &ValidationError{Problems: problems}
+	}
+	return
+}
+
+// ValidationError lists every invalid setting at once
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "config: invalid settings: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that the settings are complete and consistent
+func (c Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Port == 0 || c.Server.Port > 65535 {
+		add("API_PORT: %d is not a valid port", c.Server.Port)
+	}
+	if c.Server.GRPCPort > 65535 {
+		add("GRPC_PORT: %d is not a valid port", c.Server.GRPCPort)
+	} else if c.Server.GRPCPort == c.Server.Port {
+		add("GRPC_PORT: %d is already the API_PORT", c.Server.GRPCPort)
+	}
+	if c.Server.MetricsPort > 65535 {
+		add("METRICS_PORT: %d is not a valid port", c.Server.MetricsPort)
+	} else if c.Server.MetricsPort == c.Server.Port {
+		add("METRICS_PORT: %d is already the API_PORT", c.Server.MetricsPort)
+	} else if c.Server.MetricsPort != 0 && c.Server.MetricsPort == c.Server.GRPCPort {
+		add("METRICS_PORT: %d is already the GRPC_PORT", c.Server.MetricsPort)
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("SHUTDOWN_TIMEOUT: must be positive")
+	}
+	switch c.Server.GinMode {
+	case "debug", "release", "test":
+	default:
+		add("GIN_MODE: %q must be debug, release or test", c.Server.GinMode)
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "trace", "debug", "info", "warn", "warning", "error", "fatal", "panic":
+	default:
+		add("LOG_LEVEL: %q must be debug, info, warn or error", c.Log.Level)
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case LogFormatJSON, LogFormatText:
+	default:
+		add("LOG_FORMAT: %q must be %s or %s", c.Log.Format, LogFormatJSON, LogFormatText)
+	}
+
+	switch c.Tracing.Exporter {
+	case TraceExporterNone, TraceExporterStdout:
+	case TraceExporterOTLP:
+		if c.Tracing.OTLPEndpoint == "" {
+			add("OTEL_EXPORTER_OTLP_ENDPOINT: is required for the otlp exporter")
+		}
+	default:
+		add("TRACING_EXPORTER: %q must be %s, %s or %s", c.Tracing.Exporter, TraceExporterNone, TraceExporterStdout, TraceExporterOTLP)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("TRACING_SAMPLE_RATIO: %v must be between 0 and 1", c.Tracing.SampleRatio)
+	}
+
+	if c.DB.SlowQuery < 0 {
+		add("DB_SLOW_QUERY: must not be negative")
+	}
+	switch c.DB.Driver {
+	case DriverMySQL:
+		validateSQLServer("MYSQL", c.DB.MySQL, add)
+	case DriverPostgres:
+		validateSQLServer("POSTGRES", c.DB.Postgres, add)
+	case DriverSQLite:
+		if c.DB.SQLitePath == "" {
+			add("SQLITE_PATH: is required")
+		}
+	default:
+		add("DB_DRIVER: %q must be %s, %s or %s", c.DB.Driver, DriverMySQL, DriverPostgres, DriverSQLite)
+	}
+
+	if c.Auth.JWKSURL == "" && c.Auth.JWKSFile == "" {
+		add("JWKS_URL or JWKS_FILE: one is required")
+	}
+	if c.Auth.JWKSURL != "" {
+		if u, err := url.Parse(c.Auth.JWKSURL); err != nil || u.Scheme == "" || u.Host == "" {
+			add("JWKS_URL: %q is not an absolute URL", c.Auth.JWKSURL)
+		}
+	}
+	if c.Auth.JWKSRefresh <= 0 {
+		add("JWKS_REFRESH: must be positive")
+	}
+	if c.Auth.Leeway < 0 {
+		add("JWT_LEEWAY: must not be negative")
+	}
+
+	if len(c.CORS.AllowOrigins) == 0 {
+		add("CORS_ALLOW_ORIGINS: at least one origin is required")
+	}
+	for _, origin := range c.CORS.AllowOrigins {
+		if err := validateOrigin(origin); err != nil {
+			add("CORS_ALLOW_ORIGINS: %q %s", origin, err)
+		} else if origin == "*" && c.CORS.AllowCredentials {
+			add("CORS_ALLOW_ORIGINS: \"*\" cannot be combined with CORS_ALLOW_CREDENTIALS")
+		}
+	}
+	if len(c.CORS.AllowMethods) == 0 {
+		add("CORS_ALLOW_METHODS: at least one method is required")
+	}
+	if c.CORS.MaxAge < 0 {
+		add("CORS_MAX_AGE: must not be negative")
+	}
+
+	if c.Security.HSTSMaxAge < 0 {
+		add("SECURITY_HSTS_MAX_AGE: must not be negative")
+	}
+	switch strings.ToUpper(c.Security.FrameOptions) {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		add("SECURITY_FRAME_OPTIONS: %q must be DENY or SAMEORIGIN", c.Security.FrameOptions)
+	}
+
+	switch c.RateLimit.Backend {
+	case RateLimitMemory:
+	case RateLimitRedis:
+		if c.Redis.Addr == "" {
+			add("REDIS_ADDR: is required for the redis rate limiter")
+		}
+	default:
+		add("RATE_LIMIT_BACKEND: %q must be %s or %s", c.RateLimit.Backend, RateLimitMemory, RateLimitRedis)
+	}
+	validateLimit("RATE_LIMIT_READ", c.RateLimit.Read, add)
+	validateLimit("RATE_LIMIT_ORDER", c.RateLimit.Order, add)
+
+	if c.Idempotency.TTL <= 0 {
+		add("IDEMPOTENCY_TTL: must be positive")
+	}
+	if c.Idempotency.Lease <= 0 {
+		add("IDEMPOTENCY_LEASE: must be positive")
+	}
+
+	if !c.Wallet.StartBalance.IsPositive() {
+		add("WALLET_START_BALANCE: must be greater than zero")
+	}
+
+	if c.Fund.APIURL != "" {
+		if u, err := url.Parse(c.Fund.APIURL); err != nil || u.Scheme == "" || u.Host == "" {
+			add("FUND_API_URL: %q is not an absolute URL", c.Fund.APIURL)
+		}
+	}
+	if c.Fund.RefreshInterval < 0 {
+		add("FUND_REFRESH_INTERVAL: must not be negative")
+	} else if c.Fund.RefreshInterval > 0 && c.Fund.SeedFile == "" && c.Fund.APIURL == "" {
+		add("FUND_REFRESH_INTERVAL: needs FUND_SEED_FILE or FUND_API_URL to refresh from")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validateSQLServer(prefix string, s SQLServerConfig, add func(string, ...interface{})) {
+	if s.Host == "" {
+		add("%s_HOST: is required", prefix)
+	}
+	if s.Port == 0 || s.Port > 65535 {
+		add("%s_PORT: %d is not a valid port", prefix, s.Port)
+	}
+	if s.User == "" {
+		add("%s_USER: is required", prefix)
+	}
+	if s.Name == "" {
+		add("%s_DB: is required", prefix)
+	}
+}
+
+func validateLimit(prefix string, l LimitConfig, add func(string, ...interface{})) {
+	if l.Rate < 0 {
+		add("%s_RATE: must not be negative", prefix)
+	}
+	if l.Rate > 0 && l.Burst < 1 {
+		add("%s_BURST: must be at least 1", prefix)
+	}
+}
+
+// validateOrigin accepts "*", scheme://host[:port] and scheme://*.domain[:port]
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+		return errors.New("must look like https://example.com")
+	}
+	host := strings.TrimPrefix(u.Host, "*.")
+	if strings.Contains(host, "*") {
+		return errors.New("may only use a wildcard as the first subdomain, as in https://*.example.com")
+	}
+	return nil
+}
+
+// envReader overrides settings from environment variables that are set,
+// collecting parse failures instead of stopping at the first one
+type envReader struct {
+	problems []string
+}
+
+func (r *envReader) lookup(key string) (string, bool) {
+	v, ok := os.LookupEnv(key)
+	if !ok || strings.TrimSpace(v) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(v), true
+}
+
+func (r *envReader) string(key string, dst *string) {
+	if v, ok := r.lookup(key); ok {
+		*dst = v
+	}
+}
+
+func (r *envReader) uint(key string, dst *uint64) {
+	if v, ok := r.lookup(key); ok {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			r.problems = append(r.problems, fmt.Sprintf("%s: %q is not a number", key, v))
+			return
+		}
+		*dst = n
+	}
+}
+
+func (r *envReader) bool(key string, dst *bool) {
+	if v, ok := r.lookup(key); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			r.problems = append(r.problems, fmt.Sprintf("%s: %q is not true or false", key, v))
+			return
+		}
+		*dst = b
+	}
+}
+
+func (r *envReader) int(key string, dst *int) {
+	if v, ok := r.lookup(key); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			r.problems = append(r.problems, fmt.Sprintf("%s: %q is not a whole number", key, v))
+			return
+		}
+		*dst = n
+	}
+}
+
+func (r *envReader) float(key string, dst *float64) {
+	if v, ok := r.lookup(key); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			r.problems = append(r.problems, fmt.Sprintf("%s: %q is not a number", key, v))
+			return
+		}
+		*dst = f
+	}
+}
+
+func (r *envReader) duration(key string, dst *time.Duration) {
+	if v, ok := r.lookup(key); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			r.problems = append(r.problems, fmt.Sprintf("%s: %q is not a duration such as 30s or 15m", key, v))
+			return
+		}
+		*dst = d
+	}
+}
+
+func (r *envReader) decimal(key string, dst *decimal.Decimal) {
+	if v, ok := r.lookup(key); ok {
+		d, err := decimal.NewFromString(v)
+		if err != nil {
+			r.problems = append(r.problems, fmt.Sprintf("%s: %q is not a decimal number", key, v))
+			return
+		}
+		*dst = d
+	}
+}
+
+// list reads a comma-separated value
+func (r *envReader) list(key string, dst *[]string) {
+	if v, ok := r.lookup(key); ok {
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*dst = items
+	}
+}
+
+func (r *envReader) sqlServer(prefix string, dst *SQLServerConfig) {
+	r.string(prefix+"_HOST", &dst.Host)
+	r.uint(prefix+"_PORT", &dst.Port)
+	r.string(prefix+"_USER", &dst.User)
+	r.string(prefix+"_PWD", &dst.Password)
+	r.string(prefix+"_DB", &dst.Name)
+}
@@ -2,49 +2,54 @@ package db
 
 import (
 	"fmt"
-	"log"
-	"os"
-	"strconv"
 
-	"gitlab.com/investio/backend/sim-api/v1/model"
+	"github.com/glebarez/sqlite"
+	"gitlab.com/investio/backend/sim-api/config"
+	"gitlab.com/investio/backend/sim-api/logging"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// Supported values of DB_DRIVER
+const (
+	DriverMySQL    = config.DriverMySQL
+	DriverPostgres = config.DriverPostgres
+	DriverSQLite   = config.DriverSQLite
+)
+
 var (
 	SimDB *gorm.DB
 )
 
-type MariaDbConfig struct {
-	Host     string
-	Port     uint64
-	User     string
-	DbName   string
-	Password string
-}
-
-func SetupDB() (err error) {
-	SimDB, err = gorm.Open(
-		mysql.Open(
-			mySqlURL(buildDbConfig(
-				os.Getenv("MYSQL_HOST"),
-				os.Getenv("MYSQL_PORT"),
-				os.Getenv("MYSQL_USER"),
-				os.Getenv("MYSQL_PWD"),
-				os.Getenv("MYSQL_DB"),
-			)),
-		),
-		&gorm.Config{},
-	)
-
+// SetupDB connects to the database chosen by cfg.Driver.
+// The schema is managed by the migrations in db/migrations, see Migrator.
+//
+// Decimal columns are declared as decimal(p,s) on every backend: MySQL and
+// PostgreSQL (as numeric) store them exactly, SQLite stores them with NUMERIC
+// affinity, which is exact up to 15 significant digits.
+func SetupDB(cfg config.DBConfig) (err error) {
+	dialector, err := newDialector(cfg)
 	if err != nil {
-		log.Fatalln("Database Init error: ", err)
 		return
 	}
-	SimDB.AutoMigrate(&model.Port{})
-	SimDB.AutoMigrate(&model.PortFund{})
-	SimDB.AutoMigrate(&model.Wallet{})
-	SimDB.AutoMigrate(&model.Transaction{})
+
+	SimDB, err = gorm.Open(dialector, &gorm.Config{
+		Logger: logging.NewGormLogger(cfg.SlowQuery),
+	})
+	if err != nil {
+		return fmt.Errorf("database init: %w", err)
+	}
+
+	if cfg.Driver == DriverSQLite {
+		// SQLite allows a single writer; one connection avoids "database is locked"
+		// and keeps an in-memory database alive for the whole process
+		sqlDB, err := SimDB.DB()
+		if err != nil {
+			return err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
 
 	// InfluxClient = influxdb2.NewClient(
 	// 	os.Getenv("INFLUX_HOST"),
@@ -55,28 +60,59 @@ func SetupDB() (err error) {
 	return
 }
 
-func buildDbConfig(host string, port string, user, pwd, dbName string) *MariaDbConfig {
-	portUint, err := strconv.ParseUint(port, 10, 32)
+// Close releases the connection pool, after the server stopped taking requests
+func Close() error {
+	if SimDB == nil {
+		return nil
+	}
+	sqlDB, err := SimDB.DB()
 	if err != nil {
-		log.Fatalln("Failed Build DB Config: ", err)
+		return err
 	}
-	dbConfig := MariaDbConfig{
-		Host:     host,
-		Port:     portUint,
-		User:     user,
-		Password: pwd,
-		DbName:   dbName,
+	return sqlDB.Close()
+}
+
+func newDialector(cfg config.DBConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case DriverMySQL:
+		return mysql.Open(mySqlURL(cfg.MySQL)), nil
+	case DriverPostgres:
+		return postgres.Open(postgresURL(cfg.Postgres)), nil
+	case DriverSQLite:
+		return sqlite.Open(sqliteURL(cfg.SQLitePath)), nil
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q, use %s, %s or %s", cfg.Driver, DriverMySQL, DriverPostgres, DriverSQLite)
 	}
-	return &dbConfig
 }
 
-func mySqlURL(dbConfig *MariaDbConfig) string {
+func mySqlURL(dbConfig config.SQLServerConfig) string {
 	return fmt.Sprintf(
 		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		dbConfig.User,
 		dbConfig.Password,
 		dbConfig.Host,
 		dbConfig.Port,
-		dbConfig.DbName,
+		dbConfig.Name,
+	)
+}
+
+func postgresURL(dbConfig config.SQLServerConfig) string {
+	sslMode := dbConfig.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		dbConfig.Host,
+		dbConfig.Port,
+		dbConfig.User,
+		dbConfig.Password,
+		dbConfig.Name,
+		sslMode,
 	)
 }
+
+// sqliteURL uses a local file, or a private in-memory database for ":memory:"
+func sqliteURL(path string) string {
+	return fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", path)
+}
@@ -0,0 +1,192 @@
+package db_test
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/db"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The models as they were when the schema came from AutoMigrate, before migrations existed
+
+type baselinePort struct {
+	ID                 uint `gorm:"primaryKey"`
+	PortName           string
+	UserID             uint
+	ProfitLossRealized decimal.Decimal `gorm:"type:decimal(12,2);"`
+	AllCost            decimal.Decimal `gorm:"type:decimal(12,2);"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeletedAt          gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselinePort) TableName() string { return "port" }
+
+type baselinePortFund struct {
+	ID         uint `gorm:"primaryKey"`
+	FundID     string
+	FundCode   string
+	BcatID     uint8
+	PortID     uint
+	Cost       decimal.Decimal `gorm:"type:decimal(12,2);"`
+	Unit       decimal.Decimal `gorm:"type:decimal(18,8);"`
+	PlRealized decimal.Decimal `gorm:"type:decimal(12,2);"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselinePortFund) TableName() string { return "port_fund" }
+
+type baselineWallet struct {
+	ID           uint            `gorm:"primaryKey"`
+	AvaliableBal decimal.Decimal `gorm:"type:decimal(12,2);"`
+	InOrderBal   decimal.Decimal `gorm:"type:decimal(12,2);"`
+	InAssetBal   decimal.Decimal `gorm:"type:decimal(12,2);"`
+	TotalSpend   decimal.Decimal `gorm:"type:decimal(12,2);"`
+	UserID       uint
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineWallet) TableName() string { return "wallet" }
+
+type baselineTransaction struct {
+	ID        uint      `gorm:"primaryKey"`
+	DataDate  time.Time `gorm:"type:date;"`
+	Type      uint32
+	UserID    uint
+	PortID    uint
+	FundID    string
+	FundCode  string
+	BcatID    uint8
+	NAV       decimal.Decimal `gorm:"type:decimal(14,4);"`
+	Amount    decimal.Decimal `gorm:"type:decimal(12,2);"`
+	Unit      decimal.Decimal `gorm:"type:decimal(18,8);"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineTransaction) TableName() string { return "transaction" }
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	gormDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "sim.db")), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return gormDB
+}
+
+func migrateUp(t *testing.T, gormDB *gorm.DB) {
+	t.Helper()
+
+	migrator, err := db.NewMigrator(gormDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.CheckSchema(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// columns lists the columns of every table but schema_migrations, as table.column
+func columns(t *testing.T, gormDB *gorm.DB) []string {
+	t.Helper()
+
+	tables, err := gormDB.Migrator().GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result []string
+	for _, table := range tables {
+		if table == "schema_migrations" || table == "sqlite_sequence" {
+			continue
+		}
+		types, err := gormDB.Migrator().ColumnTypes(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range types {
+			result = append(result, table+"."+c.Name())
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestUpAdoptsBaselineSchema(t *testing.T) {
+	baseline := openSQLite(t)
+	err := baseline.AutoMigrate(&baselinePort{}, &baselinePortFund{}, &baselineWallet{}, &baselineTransaction{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrateUp(t, baseline)
+
+	fresh := openSQLite(t)
+	migrateUp(t, fresh)
+
+	got, want := columns(t, baseline), columns(t, fresh)
+	if len(got) != len(want) {
+		t.Fatalf("columns after migrating the baseline:\n%v\nwant the columns of a new database:\n%v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("columns after migrating the baseline:\n%v\nwant the columns of a new database:\n%v", got, want)
+		}
+	}
+
+	// Wallets, which gained columns since the baseline, can be read and saved
+	ctx := context.Background()
+	wallets := repository.NewWalletRepository(baseline)
+	wallet := model.Wallet{UserID: 7, AvailableBal: decimal.NewFromInt(100)}
+	if err := wallets.Create(ctx, &wallet); err != nil {
+		t.Fatal(err)
+	}
+	if err := wallets.FindByUser(ctx, &wallet, 7); err != nil {
+		t.Fatal(err)
+	}
+	wallet.IsFrozen = true
+	if err := wallets.Save(ctx, &wallet); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDownUndoesEveryMigration(t *testing.T) {
+	gormDB := openSQLite(t)
+	migrateUp(t, gormDB)
+
+	migrator, err := db.NewMigrator(gormDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Down(migrator.Latest()); err != nil {
+		t.Fatal(err)
+	}
+	if got := columns(t, gormDB); len(got) != 0 {
+		t.Errorf("columns left after rolling back every migration: %v", got)
+	}
+	migrateUp(t, gormDB)
+}
@@ -0,0 +1,6 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations.
+-- IF NOT EXISTS lets databases created that way adopt the migrations.
+CREATE TABLE IF NOT EXISTS `port` (`id` bigint unsigned AUTO_INCREMENT,`port_name` longtext,`user_id` bigint unsigned,`profit_loss_realized` decimal(12,2),`all_cost` decimal(12,2),`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_port_deleted_at` (`deleted_at`));
+CREATE TABLE IF NOT EXISTS `port_fund` (`id` bigint unsigned AUTO_INCREMENT,`fund_id` longtext,`fund_code` longtext,`bcat_id` tinyint unsigned,`port_id` bigint unsigned,`cost` decimal(12,2),`unit` decimal(18,8),`pl_realized` decimal(12,2),`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_port_fund_deleted_at` (`deleted_at`));
+CREATE TABLE IF NOT EXISTS `wallet` (`id` bigint unsigned AUTO_INCREMENT,`avaliable_bal` decimal(12,2),`in_order_bal` decimal(12,2),`in_asset_bal` decimal(12,2),`total_spend` decimal(12,2),`user_id` bigint unsigned,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_wallet_deleted_at` (`deleted_at`));
+CREATE TABLE IF NOT EXISTS `transaction` (`id` bigint unsigned AUTO_INCREMENT,`data_date` date,`type` int unsigned,`user_id` bigint unsigned,`port_id` bigint unsigned,`fund_id` longtext,`fund_code` longtext,`bcat_id` tinyint unsigned,`nav` decimal(14,4),`amount` decimal(12,2),`unit` decimal(18,8),`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_transaction_deleted_at` (`deleted_at`));
@@ -0,0 +1 @@
+CREATE TABLE `fund` (`id` varchar(32),`code` varchar(64),`name_th` longtext,`name_en` longtext,`amc_code` varchar(32),`amc_name` longtext,`bcat_id` tinyint unsigned,`category_name` longtext,`risk_level` tinyint unsigned,`currency` varchar(3),`dividend_policy` varchar(16),`status` varchar(16),`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_fund_code` (`code`),INDEX `idx_fund_status` (`status`));
@@ -0,0 +1 @@
+ALTER TABLE `wallet` ADD `is_frozen` boolean;
@@ -0,0 +1 @@
+CREATE TABLE `audit_log` (`id` bigint unsigned AUTO_INCREMENT,`admin_id` bigint unsigned,`user_id` bigint unsigned,`action` varchar(32),`amount` decimal(12,2),`balance_before` decimal(12,2),`balance_after` decimal(12,2),`reason` longtext,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_audit_log_admin_id` (`admin_id`),INDEX `idx_audit_log_user_id` (`user_id`));
@@ -0,0 +1 @@
+ALTER TABLE `wallet` CHANGE `available_bal` `avaliable_bal` decimal(12,2);
@@ -0,0 +1 @@
+ALTER TABLE `wallet` CHANGE `avaliable_bal` `available_bal` decimal(12,2);
@@ -0,0 +1 @@
+CREATE TABLE `idempotency_key` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned,`key` varchar(255),`request_hash` varchar(64),`completed` boolean,`response_status` bigint,`response_type` varchar(128),`response_body` longtext,`error_code` varchar(64),`created_at` datetime(3) NULL,`expires_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_idempotency_key_user_key` (`user_id`,`key`),INDEX `idx_idempotency_key_expires_at` (`expires_at`));
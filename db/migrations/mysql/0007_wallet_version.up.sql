@@ -0,0 +1 @@
+ALTER TABLE `wallet` ADD `version` bigint unsigned NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE `port_fund` ADD `version` bigint unsigned NOT NULL DEFAULT 0;
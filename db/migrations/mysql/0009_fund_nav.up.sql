@@ -0,0 +1 @@
+ALTER TABLE `fund` ADD `nav` decimal(14,4) NULL;
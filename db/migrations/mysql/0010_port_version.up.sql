@@ -0,0 +1 @@
+ALTER TABLE `port` ADD `version` bigint unsigned NOT NULL DEFAULT 0;
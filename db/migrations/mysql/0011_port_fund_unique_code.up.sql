@@ -0,0 +1,3 @@
+-- Fails while a port holds the same fund twice; merge those holdings first.
+-- Fund codes are at most 64 characters, so the prefix covers the whole code.
+CREATE UNIQUE INDEX `idx_port_fund_port_code` ON `port_fund` (`port_id`,`fund_code`(64));
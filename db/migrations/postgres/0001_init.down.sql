@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "transaction";
+DROP TABLE IF EXISTS "wallet";
+DROP TABLE IF EXISTS "port_fund";
+DROP TABLE IF EXISTS "port";
@@ -0,0 +1,10 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations.
+-- IF NOT EXISTS lets databases created that way adopt the migrations.
+CREATE TABLE IF NOT EXISTS "port" ("id" bigserial,"port_name" text,"user_id" bigint,"profit_loss_realized" decimal(12,2),"all_cost" decimal(12,2),"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_port_deleted_at" ON "port" ("deleted_at");
+CREATE TABLE IF NOT EXISTS "port_fund" ("id" bigserial,"fund_id" text,"fund_code" text,"bcat_id" smallint,"port_id" bigint,"cost" decimal(12,2),"unit" decimal(18,8),"pl_realized" decimal(12,2),"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_port_fund_deleted_at" ON "port_fund" ("deleted_at");
+CREATE TABLE IF NOT EXISTS "wallet" ("id" bigserial,"avaliable_bal" decimal(12,2),"in_order_bal" decimal(12,2),"in_asset_bal" decimal(12,2),"total_spend" decimal(12,2),"user_id" bigint,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_wallet_deleted_at" ON "wallet" ("deleted_at");
+CREATE TABLE IF NOT EXISTS "transaction" ("id" bigserial,"data_date" date,"type" bigint,"user_id" bigint,"port_id" bigint,"fund_id" text,"fund_code" text,"bcat_id" smallint,"nav" decimal(14,4),"amount" decimal(12,2),"unit" decimal(18,8),"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_transaction_deleted_at" ON "transaction" ("deleted_at");
@@ -0,0 +1,3 @@
+CREATE TABLE "fund" ("id" varchar(32),"code" varchar(64),"name_th" text,"name_en" text,"amc_code" varchar(32),"amc_name" text,"bcat_id" smallint,"category_name" text,"risk_level" smallint,"currency" varchar(3),"dividend_policy" varchar(16),"status" varchar(16),"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX "idx_fund_status" ON "fund" ("status");
+CREATE UNIQUE INDEX "idx_fund_code" ON "fund" ("code");
@@ -0,0 +1,3 @@
+CREATE TABLE "audit_log" ("id" bigserial,"admin_id" bigint,"user_id" bigint,"action" varchar(32),"amount" decimal(12,2),"balance_before" decimal(12,2),"balance_after" decimal(12,2),"reason" text,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX "idx_audit_log_user_id" ON "audit_log" ("user_id");
+CREATE INDEX "idx_audit_log_admin_id" ON "audit_log" ("admin_id");
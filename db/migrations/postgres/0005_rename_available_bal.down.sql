@@ -0,0 +1 @@
+ALTER TABLE "wallet" RENAME COLUMN "available_bal" TO "avaliable_bal";
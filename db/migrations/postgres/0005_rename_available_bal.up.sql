@@ -0,0 +1 @@
+ALTER TABLE "wallet" RENAME COLUMN "avaliable_bal" TO "available_bal";
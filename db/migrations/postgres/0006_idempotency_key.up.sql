@@ -0,0 +1,3 @@
+CREATE TABLE "idempotency_key" ("id" bigserial,"user_id" bigint,"key" varchar(255),"request_hash" varchar(64),"completed" boolean,"response_status" bigint,"response_type" varchar(128),"response_body" text,"error_code" varchar(64),"created_at" timestamptz,"expires_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX "idx_idempotency_key_user_key" ON "idempotency_key" ("user_id","key");
+CREATE INDEX "idx_idempotency_key_expires_at" ON "idempotency_key" ("expires_at");
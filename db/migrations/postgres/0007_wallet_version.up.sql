@@ -0,0 +1 @@
+ALTER TABLE "wallet" ADD COLUMN "version" bigint NOT NULL DEFAULT 0;
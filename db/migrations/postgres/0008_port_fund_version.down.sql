@@ -0,0 +1 @@
+ALTER TABLE "port_fund" DROP COLUMN "version";
@@ -0,0 +1 @@
+ALTER TABLE "port_fund" ADD COLUMN "version" bigint NOT NULL DEFAULT 0;
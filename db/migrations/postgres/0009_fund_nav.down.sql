@@ -0,0 +1 @@
+ALTER TABLE "fund" DROP COLUMN "nav";
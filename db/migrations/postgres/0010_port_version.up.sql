@@ -0,0 +1 @@
+ALTER TABLE "port" ADD COLUMN "version" bigint NOT NULL DEFAULT 0;
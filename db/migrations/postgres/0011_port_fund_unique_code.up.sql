@@ -0,0 +1,2 @@
+-- Fails while a port holds the same fund twice; merge those holdings first.
+CREATE UNIQUE INDEX "idx_port_fund_port_code" ON "port_fund" ("port_id","fund_code");
@@ -0,0 +1,10 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations.
+-- IF NOT EXISTS lets databases created that way adopt the migrations.
+CREATE TABLE IF NOT EXISTS `port` (`id` integer PRIMARY KEY AUTOINCREMENT,`port_name` text,`user_id` integer,`profit_loss_realized` decimal(12,2),`all_cost` decimal(12,2),`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_port_deleted_at` ON `port`(`deleted_at`);
+CREATE TABLE IF NOT EXISTS `port_fund` (`id` integer PRIMARY KEY AUTOINCREMENT,`fund_id` text,`fund_code` text,`bcat_id` integer,`port_id` integer,`cost` decimal(12,2),`unit` decimal(18,8),`pl_realized` decimal(12,2),`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_port_fund_deleted_at` ON `port_fund`(`deleted_at`);
+CREATE TABLE IF NOT EXISTS `wallet` (`id` integer PRIMARY KEY AUTOINCREMENT,`avaliable_bal` decimal(12,2),`in_order_bal` decimal(12,2),`in_asset_bal` decimal(12,2),`total_spend` decimal(12,2),`user_id` integer,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_wallet_deleted_at` ON `wallet`(`deleted_at`);
+CREATE TABLE IF NOT EXISTS `transaction` (`id` integer PRIMARY KEY AUTOINCREMENT,`data_date` date,`type` integer,`user_id` integer,`port_id` integer,`fund_id` text,`fund_code` text,`bcat_id` integer,`nav` decimal(14,4),`amount` decimal(12,2),`unit` decimal(18,8),`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_transaction_deleted_at` ON `transaction`(`deleted_at`);
@@ -0,0 +1,3 @@
+CREATE TABLE `fund` (`id` text,`code` text,`name_th` text,`name_en` text,`amc_code` text,`amc_name` text,`bcat_id` integer,`category_name` text,`risk_level` integer,`currency` text,`dividend_policy` text,`status` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_fund_status` ON `fund`(`status`);
+CREATE UNIQUE INDEX `idx_fund_code` ON `fund`(`code`);
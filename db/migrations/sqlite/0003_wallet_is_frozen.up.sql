@@ -0,0 +1 @@
+ALTER TABLE `wallet` ADD COLUMN `is_frozen` numeric;
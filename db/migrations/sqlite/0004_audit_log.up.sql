@@ -0,0 +1,3 @@
+CREATE TABLE `audit_log` (`id` integer PRIMARY KEY AUTOINCREMENT,`admin_id` integer,`user_id` integer,`action` text,`amount` decimal(12,2),`balance_before` decimal(12,2),`balance_after` decimal(12,2),`reason` text,`created_at` datetime);
+CREATE INDEX `idx_audit_log_user_id` ON `audit_log`(`user_id`);
+CREATE INDEX `idx_audit_log_admin_id` ON `audit_log`(`admin_id`);
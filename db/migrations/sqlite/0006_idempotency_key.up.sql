@@ -0,0 +1,3 @@
+CREATE TABLE `idempotency_key` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer,`key` text,`request_hash` text,`completed` numeric,`response_status` integer,`response_type` text,`response_body` text,`error_code` text,`created_at` datetime,`expires_at` datetime);
+CREATE UNIQUE INDEX `idx_idempotency_key_user_key` ON `idempotency_key`(`user_id`,`key`);
+CREATE INDEX `idx_idempotency_key_expires_at` ON `idempotency_key`(`expires_at`);
@@ -0,0 +1 @@
+ALTER TABLE `wallet` ADD COLUMN `version` integer NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE `port_fund` ADD COLUMN `version` integer NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE `fund` ADD COLUMN `nav` decimal(14,4);
@@ -0,0 +1 @@
+ALTER TABLE `port` DROP COLUMN `version`;
@@ -0,0 +1 @@
+ALTER TABLE `port` ADD COLUMN `version` integer NOT NULL DEFAULT 0;
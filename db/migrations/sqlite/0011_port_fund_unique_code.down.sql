@@ -0,0 +1 @@
+DROP INDEX `idx_port_fund_port_code`;
@@ -0,0 +1,184 @@
+// Package events carries changes to orders, wallets and fund prices from the
+// services to the clients that stream them. The bus is in-process; every API
+// instance only sees the changes it made itself.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/investio/backend/sim-api/metrics"
+)
+
+// Type names an event; it is the event field of the stream
+type Type string
+
+const (
+	TypeOrder     Type = "order"
+	TypeWallet    Type = "wallet"
+	TypeValuation Type = "valuation"
+	// TypeNAV is published to everyone when fund NAVs change
+	TypeNAV Type = "nav"
+)
+
+// subscriptionBuffer is how many events a subscriber can fall behind before
+// new events are dropped for it
+const subscriptionBuffer = 64
+
+// Event is a change for one user, or for everyone when UserID is 0
+type Event struct {
+	// ID increases with every event published on the bus
+	ID     uint64
+	Type   Type
+	UserID uint
+	Time   time.Time
+	Data   interface{}
+}
+
+// Publisher is what the services need of the bus
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Discard publishes to no one
+var Discard Publisher = discard{}
+
+type discard struct{}
+
+func (discard) Publish(Event) {}
+
+type Bus interface {
+	Publisher
+	// Subscribe receives the events of userID and the ones for everyone.
+	// Subscribing to 0 receives every event on the bus.
+	Subscribe(userID uint) *Subscription
+	// Subscribed reports whether userID has a subscription of their own
+	Subscribed(userID uint) bool
+	// Subscribers returns the users with a subscription of their own
+	Subscribers() []uint
+	// Close ends every subscription; later events are dropped
+	Close()
+}
+
+// Subscription delivers events on C until it or the bus is closed, then C is closed
+type Subscription struct {
+	C <-chan Event
+
+	bus *memoryBus
+	sub *subscriber
+}
+
+// Close stops the delivery and closes C. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s.sub)
+}
+
+type subscriber struct {
+	userID uint
+	ch     chan Event
+}
+
+type memoryBus struct {
+	mu     sync.RWMutex
+	lastID uint64
+	subs   map[*subscriber]struct{}
+	users  map[uint]int
+	closed bool
+}
+
+func NewMemoryBus() Bus {
+	return &memoryBus{
+		subs:  make(map[*subscriber]struct{}),
+		users: make(map[uint]int),
+	}
+}
+
+// Publish never blocks: a subscriber whose buffer is full misses the event
+func (b *memoryBus) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.lastID++
+	e.ID = b.lastID
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	for sub := range b.subs {
+		if sub.userID != 0 && e.UserID != 0 && sub.userID != e.UserID {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			metrics.EventsDropped.WithLabelValues(string(e.Type)).Inc()
+		}
+	}
+}
+
+func (b *memoryBus) Subscribe(userID uint) *Subscription {
+	sub := &subscriber{userID: userID, ch: make(chan Event, subscriptionBuffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(sub.ch)
+	} else {
+		b.subs[sub] = struct{}{}
+		b.users[userID]++
+	}
+	return &Subscription{C: sub.ch, bus: b, sub: sub}
+}
+
+func (b *memoryBus) Subscribed(userID uint) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.users[userID] > 0
+}
+
+func (b *memoryBus) Subscribers() []uint {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	users := make([]uint, 0, len(b.users))
+	for userID := range b.users {
+		if userID != 0 {
+			users = append(users, userID)
+		}
+	}
+	return users
+}
+
+func (b *memoryBus) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	if b.users[sub.userID]--; b.users[sub.userID] == 0 {
+		delete(b.users, sub.userID)
+	}
+	close(sub.ch)
+}
+
+func (b *memoryBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subs {
+		close(sub.ch)
+	}
+	b.subs = nil
+	b.users = nil
+}
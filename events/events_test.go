@@ -0,0 +1,97 @@
+package events
+
+import (
+	"testing"
+)
+
+// received drains what is buffered for sub without waiting
+func received(sub *Subscription) (events []Event) {
+	for {
+		select {
+		case e := <-sub.C:
+			events = append(events, e)
+		default:
+			return
+		}
+	}
+}
+
+func TestDelivery(t *testing.T) {
+	bus := NewMemoryBus()
+	user7, user8, all := bus.Subscribe(7), bus.Subscribe(8), bus.Subscribe(0)
+
+	bus.Publish(Event{Type: TypeWallet, UserID: 7})
+	bus.Publish(Event{Type: TypeNAV})
+	bus.Publish(Event{Type: TypeOrder, UserID: 9})
+
+	for _, tc := range []struct {
+		name string
+		sub  *Subscription
+		want []Type
+	}{
+		{"user 7", user7, []Type{TypeWallet, TypeNAV}},
+		{"user 8", user8, []Type{TypeNAV}},
+		{"everything", all, []Type{TypeWallet, TypeNAV, TypeOrder}},
+	} {
+		got := received(tc.sub)
+		if len(got) != len(tc.want) {
+			t.Errorf("%s received %d events, want %d", tc.name, len(got), len(tc.want))
+			continue
+		}
+		for i, e := range got {
+			if e.Type != tc.want[i] || e.Time.IsZero() {
+				t.Errorf("%s event %d = %s at %v, want %s", tc.name, i, e.Type, e.Time, tc.want[i])
+			}
+			if i > 0 && e.ID <= got[i-1].ID {
+				t.Errorf("%s event IDs %d then %d do not increase", tc.name, got[i-1].ID, e.ID)
+			}
+		}
+	}
+
+	if !bus.Subscribed(7) || bus.Subscribed(9) {
+		t.Error("Subscribed does not match the subscriptions")
+	}
+	user7.Close()
+	user7.Close()
+	if bus.Subscribed(7) {
+		t.Error("user 7 still subscribed after Close")
+	}
+	if _, ok := <-user7.C; ok {
+		t.Error("channel of a closed subscription is open")
+	}
+	if got := bus.Subscribers(); len(got) != 1 || got[0] != 8 {
+		t.Errorf("Subscribers = %v, want [8]", got)
+	}
+}
+
+func TestSlowSubscriberMissesEvents(t *testing.T) {
+	bus := NewMemoryBus()
+	slow := bus.Subscribe(7)
+
+	// Publish must not block on a subscriber that does not read
+	for i := 0; i < subscriptionBuffer+10; i++ {
+		bus.Publish(Event{Type: TypeWallet, UserID: 7})
+	}
+	if got := len(received(slow)); got != subscriptionBuffer {
+		t.Errorf("slow subscriber received %d events, want the %d that fit its buffer", got, subscriptionBuffer)
+	}
+}
+
+func TestCloseEndsSubscriptions(t *testing.T) {
+	bus := NewMemoryBus()
+	sub := bus.Subscribe(7)
+
+	bus.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("subscription open after the bus closed")
+	}
+	sub.Close()
+
+	// Later subscriptions end at once and events go nowhere
+	late := bus.Subscribe(7)
+	bus.Publish(Event{Type: TypeWallet, UserID: 7})
+	if _, ok := <-late.C; ok {
+		t.Error("subscription to a closed bus is open")
+	}
+	bus.Close()
+}
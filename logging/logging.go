@@ -0,0 +1,93 @@
+// Package logging configures the logrus logger and carries the request fields
+// (request_id, user_id, route) in the request context, so every log line of a
+// request, including GORM's SQL logs, can be correlated. Lines logged inside a
+// span also carry its trace_id and span_id.
+package logging
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"gitlab.com/investio/backend/sim-api/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// requestInfo is shared by pointer, so middleware later in the chain, such as
+// authentication, can add the user to a context created earlier
+type requestInfo struct {
+	mu        sync.RWMutex
+	requestID string
+	route     string
+	userID    uint
+}
+
+// Setup configures the standard logrus logger, which every package logs through
+func Setup(cfg config.LogConfig) error {
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	logrus.SetLevel(level)
+	logrus.SetOutput(os.Stdout)
+
+	if strings.EqualFold(cfg.Format, config.LogFormatText) {
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	} else {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	}
+	return nil
+}
+
+// WithRequest starts the log fields of a request
+func WithRequest(ctx context.Context, requestID, route string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestInfo{
+		requestID: requestID,
+		route:     route,
+	})
+}
+
+// SetUserID adds the authenticated user to the fields of the request in ctx
+func SetUserID(ctx context.Context, userID uint) {
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		info.mu.Lock()
+		info.userID = userID
+		info.mu.Unlock()
+	}
+}
+
+// RequestID returns the ID of the request in ctx, or "" outside a request
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		return info.requestID
+	}
+	return ""
+}
+
+// FromContext returns a log entry with the fields of the request in ctx
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	if ctx == nil {
+		return entry
+	}
+	fields := logrus.Fields{}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields["trace_id"] = sc.TraceID().String()
+		fields["span_id"] = sc.SpanID().String()
+	}
+
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		info.mu.RLock()
+		fields["request_id"] = info.requestID
+		fields["route"] = info.route
+		if info.userID != 0 {
+			fields["user_id"] = info.userID
+		}
+		info.mu.RUnlock()
+	}
+	return entry.WithContext(ctx).WithFields(fields)
+}
@@ -1,76 +1,124 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-	"github.com/sirupsen/logrus"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/investio/backend/sim-api/config"
 	"gitlab.com/investio/backend/sim-api/db"
-	"gitlab.com/investio/backend/sim-api/v1/controller"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/metrics"
+	"gitlab.com/investio/backend/sim-api/ratelimit"
+	"gitlab.com/investio/backend/sim-api/server"
+	"gitlab.com/investio/backend/sim-api/tracing"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
 	"gitlab.com/investio/backend/sim-api/v1/service"
+	"gitlab.com/investio/backend/sim-api/version"
+	otelgorm "gorm.io/plugin/opentelemetry/tracing"
 )
 
-var (
-	log = logrus.New()
+// newRateLimiter picks the rate limiter backend. The close function releases its connections.
+func newRateLimiter(cfg config.Config) (limiter ratelimit.Limiter, close func() error) {
+	if cfg.RateLimit.Backend == config.RateLimitRedis {
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		return ratelimit.NewRedisLimiter(client), client.Close
+	}
+	return ratelimit.NewMemoryLimiter(), func() error { return nil }
+}
+
+func main() {
+	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-version") {
+		fmt.Println(version.Get())
+		return
+	}
 
-	authService        = service.NewAuthService()
-	portService        = service.NewPortService()
-	walletService      = service.NewWalletService()
-	transactionService = service.NewTransctionService()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		log.Fatal("Main: ", err)
+	}
+	gin.SetMode(cfg.Server.GinMode)
 
-	portController        = controller.NewPortController(authService, portService, walletService, transactionService)
-	walletController      = controller.NewWalletController(authService, walletService)
-	transactionController = controller.NewTransactionController(authService, transactionService)
-)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal("Main: Set up tracing failed ", err)
+	}
+
+	if err := db.SetupDB(cfg.DB); err != nil {
+		log.Fatal("Main: ", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
+	if err := db.SimDB.Use(&metrics.GormPlugin{DBName: cfg.DB.Driver}); err != nil {
+		log.Fatal("Main: ", err)
+	}
+	if err := db.SimDB.Use(otelgorm.NewPlugin(otelgorm.WithoutMetrics())); err != nil {
+		log.Fatal("Main: ", err)
+	}
 
-func getVersion(ctx *gin.Context) {
-	ctx.JSON(200, gin.H{
-		"version": "1.0.2",
+	migrator, err := checkSchema()
+	if err != nil {
+		log.Fatal("Main: ", err, " - run `main migrate up` first")
+	}
+
+	sqlDB, err := db.SimDB.DB()
+	if err != nil {
+		log.Fatal("Main: ", err)
+	}
+
+	keySet, err := service.NewKeySet(cfg.Auth)
+	if err != nil {
+		log.Panic("Main: Load JWKS failed ", err)
+	}
+
+	rateLimiter, closeRateLimiter := newRateLimiter(cfg)
+
+	srv := server.New(cfg, server.Deps{
+		Repos:       repository.NewGormRepositories(db.SimDB),
+		Transactor:  repository.NewGormTransactor(db.SimDB),
+		Keys:        keySet,
+		Health:      service.NewHealthService(sqlDB, migrator),
+		RateLimiter: rateLimiter,
 	})
-}
+	srv.LoadFundCatalogue(context.Background())
 
-func main() {
-	if os.Getenv("GIN_MODE") != "release" {
-		err := godotenv.Load()
-		if err != nil {
-			log.Warn("Main: Not using .env file")
-		}
-	}
-
-	if err := db.SetupDB(); err != nil {
-		log.Panic(err)
-	}
-
-	r := gin.Default()
-
-	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowOrigins = []string{"http://localhost:2564", "http://192.168.50.121:3003", "https://investio.dewkul.me", "https://investio.netlify.app"}
-	// corsConfig.AllowMethods = []string{"PUT"}
-	corsConfig.AllowHeaders = []string{"Authorization", "content-type"}
-	// To be able to send tokens to the server.
-	corsConfig.AllowCredentials = true
-
-	// OPTIONS method for VueJS
-	corsConfig.AddAllowMethods("OPTIONS")
-	r.Use(cors.New(corsConfig))
-
-	v1 := r.Group("/sim/v1")
-	{
-		v1.GET("/port", portController.GetFundsInPort)
-		p := v1.Group("/port")
-		{
-			p.POST("/buy", portController.BuyFund)
-			p.POST("/sell", portController.SellFund)
-		}
-		v1.GET("/wallet", walletController.GetWallet)
-		v1.GET("/orders", transactionController.GetTransaction)
-		v1.GET("/ver", getVersion)
-	}
-	port := os.Getenv("API_PORT")
-	if port == "" {
-		port = "5005"
-	}
-	log.Panic(r.Run(":" + port))
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		// A second signal while draining stops the process at once
+		<-ctx.Done()
+		stop()
+	}()
+	if err := srv.Run(ctx); err != nil {
+		log.Error("Main: ", err)
+	}
+
+	if err := closeRateLimiter(); err != nil {
+		log.Error("Main: Close rate limiter failed ", err)
+	}
+	if err := db.Close(); err != nil {
+		log.Error("Main: Close database failed ", err)
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Error("Main: Flush traces failed ", err)
+	}
+	log.Info("Main: Stopped")
 }
@@ -0,0 +1,104 @@
+// Package metrics defines the Prometheus collectors of the API, served at /metrics on the metrics port
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "sim"
+
+// Order sides and reversal steps used as label values
+const (
+	SideBuy     = "buy"
+	SideSell    = "sell"
+	SideUnknown = "unknown"
+
+	StepWallet = "wallet"
+	StepPort   = "port"
+
+	// CodeOK labels orders that completed
+	CodeOK = "OK"
+)
+
+// Registry holds every collector below, plus the Go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	Orders = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_total",
+		Help:      "Buy and sell orders by outcome, OK or the error code.",
+	}, []string{"side", "code"})
+
+	// ReversalFailures counts compensating steps that failed after an order
+	// failed half-way, which leaves the wallet and port inconsistent
+	ReversalFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reversal_failures_total",
+		Help:      "Failed compensating reversals by order side and the step that could not be reversed.",
+	}, []string{"side", "step"})
+
+	// EventsDropped counts events a subscriber missed because it fell behind
+	EventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "dropped_total",
+		Help:      "Events not delivered to a subscriber whose buffer was full, by event type.",
+	}, []string{"type"})
+
+	// StreamDuration is kept apart from HTTPRequestDuration, whose buckets a stream
+	// open for minutes would distort
+	StreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "stream_duration_seconds",
+		Help:      "How long event streams stayed open, by route.",
+		Buckets:   []float64{1, 10, 30, 60, 300, 900, 1800, 3600, 7200},
+	}, []string{"route"})
+
+	Streams = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "open_streams",
+		Help:      "Event streams currently open.",
+	})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Database query latency by operation, table and status.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		Orders,
+		ReversalFailures,
+		EventsDropped,
+		StreamDuration,
+		Streams,
+		DBQueryDuration,
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/investio/backend/sim-api/db"
+)
+
+const migrateUsage = "usage: main migrate up | down [steps] | status"
+
+// runMigrate handles `main migrate <up|down|status>` and returns the exit code
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	migrator, err := db.NewMigrator(db.SimDB)
+	if err != nil {
+		log.Error("Migrate: ", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up()
+		for _, m := range done {
+			log.Infof("Migrate: applied %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Error("Migrate: ", err)
+			return 1
+		}
+		if len(done) == 0 {
+			log.Info("Migrate: schema is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		done, err := migrator.Down(steps)
+		for _, m := range done {
+			log.Infof("Migrate: rolled back %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Error("Migrate: ", err)
+			return 1
+		}
+
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			log.Error("Migrate: ", err)
+			return 1
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-32s %s\n", s.Version, s.Name, applied)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
+
+// checkSchema refuses to serve until every migration is applied
+func checkSchema() (migrator *db.Migrator, err error) {
+	migrator, err = db.NewMigrator(db.SimDB)
+	if err != nil {
+		return
+	}
+	err = migrator.CheckSchema()
+	return
+}
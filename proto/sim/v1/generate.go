@@ -0,0 +1,5 @@
+// Package simv1 is the gRPC API of the simulator for other backend services,
+// generated from simulator.proto.
+package simv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative sim/v1/simulator.proto
@@ -0,0 +1,865 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: sim/v1/simulator.proto
+
+package simv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type OrderSide int32
+
+const (
+	OrderSide_ORDER_SIDE_UNSPECIFIED OrderSide = 0
+	OrderSide_ORDER_SIDE_BUY         OrderSide = 1
+	OrderSide_ORDER_SIDE_SELL        OrderSide = 2
+)
+
+// Enum value maps for OrderSide.
+var (
+	OrderSide_name = map[int32]string{
+		0: "ORDER_SIDE_UNSPECIFIED",
+		1: "ORDER_SIDE_BUY",
+		2: "ORDER_SIDE_SELL",
+	}
+	OrderSide_value = map[string]int32{
+		"ORDER_SIDE_UNSPECIFIED": 0,
+		"ORDER_SIDE_BUY":         1,
+		"ORDER_SIDE_SELL":        2,
+	}
+)
+
+func (x OrderSide) Enum() *OrderSide {
+	p := new(OrderSide)
+	*p = x
+	return p
+}
+
+func (x OrderSide) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderSide) Descriptor() protoreflect.EnumDescriptor {
+	return file_sim_v1_simulator_proto_enumTypes[0].Descriptor()
+}
+
+func (OrderSide) Type() protoreflect.EnumType {
+	return &file_sim_v1_simulator_proto_enumTypes[0]
+}
+
+func (x OrderSide) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderSide.Descriptor instead.
+func (OrderSide) EnumDescriptor() ([]byte, []int) {
+	return file_sim_v1_simulator_proto_rawDescGZIP(), []int{0}
+}
+
+type GetPortfolioRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPortfolioRequest) Reset() {
+	*x = GetPortfolioRequest{}
+	mi := &file_sim_v1_simulator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPortfolioRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPortfolioRequest) ProtoMessage() {}
+
+func (x *GetPortfolioRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sim_v1_simulator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPortfolioRequest.ProtoReflect.Descriptor instead.
+func (*GetPortfolioRequest) Descriptor() ([]byte, []int) {
+	return file_sim_v1_simulator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetPortfolioRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type Portfolio struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PortId        uint64                 `protobuf:"varint,1,opt,name=port_id,json=portId,proto3" json:"port_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	RealizedPl    string                 `protobuf:"bytes,3,opt,name=realized_pl,json=realizedPl,proto3" json:"realized_pl,omitempty"`
+	TotalCost     string                 `protobuf:"bytes,4,opt,name=total_cost,json=totalCost,proto3" json:"total_cost,omitempty"`
+	Holdings      []*Holding             `protobuf:"bytes,5,rep,name=holdings,proto3" json:"holdings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Portfolio) Reset() {
+	*x = Portfolio{}
+	mi := &file_sim_v1_simulator_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Portfolio) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Portfolio) ProtoMessage() {}
+
+func (x *Portfolio) ProtoReflect() protoreflect.Message {
+	mi := &file_sim_v1_simulator_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Portfolio.ProtoReflect.Descriptor instead.
+func (*Portfolio) Descriptor() ([]byte, []int) {
+	return file_sim_v1_simulator_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Portfolio) GetPortId() uint64 {
+	if x != nil {
+		return x.PortId
+	}
+	return 0
+}
+
+func (x *Portfolio) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Portfolio) GetRealizedPl() string {
+	if x != nil {
+		return x.RealizedPl
+	}
+	return ""
+}
+
+func (x *Portfolio) GetTotalCost() string {
+	if x != nil {
+		return x.TotalCost
+	}
+	return ""
+}
+
+func (x *Portfolio) GetHoldings() []*Holding {
+	if x != nil {
+		return x.Holdings
+	}
+	return nil
+}
+
+type Holding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FundId        string                 `protobuf:"bytes,1,opt,name=fund_id,json=fundId,proto3" json:"fund_id,omitempty"`
+	FundCode      string                 `protobuf:"bytes,2,opt,name=fund_code,json=fundCode,proto3" json:"fund_code,omitempty"`
+	CategoryId    uint32                 `protobuf:"varint,3,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	Cost          string                 `protobuf:"bytes,4,opt,name=cost,proto3" json:"cost,omitempty"`
+	Units         string                 `protobuf:"bytes,5,opt,name=units,proto3" json:"units,omitempty"`
+	RealizedPl    string                 `protobuf:"bytes,6,opt,name=realized_pl,json=realizedPl,proto3" json:"realized_pl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Holding) Reset() {
+	*x = Holding{}
+	mi := &file_sim_v1_simulator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Holding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Holding) ProtoMessage() {}
+
+func (x *Holding) ProtoReflect() protoreflect.Message {
+	mi := &file_sim_v1_simulator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Holding.ProtoReflect.Descriptor instead.
+func (*Holding) Descriptor() ([]byte, []int) {
+	return file_sim_v1_simulator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Holding) GetFundId() string {
+	if x != nil {
+		return x.FundId
+	}
+	return ""
+}
+
+func (x *Holding) GetFundCode() string {
+	if x != nil {
+		return x.FundCode
+	}
+	return ""
+}
+
+func (x *Holding) GetCategoryId() uint32 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *Holding) GetCost() string {
+	if x != nil {
+		return x.Cost
+	}
+	return ""
+}
+
+func (x *Holding) GetUnits() string {
+	if x != nil {
+		return x.Units
+	}
+	return ""
+}
+
+func (x *Holding) GetRealizedPl() string {
+	if x != nil {
+		return x.RealizedPl
+	}
+	return ""
+}
+
+type GetWalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWalletRequest) Reset() {
+	*x = GetWalletRequest{}
+	mi := &file_sim_v1_simulator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWalletRequest) ProtoMessage() {}
+
+func (x *GetWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sim_v1_simulator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWalletRequest.ProtoReflect.Descriptor instead.
+func (*GetWalletRequest) Descriptor() ([]byte, []int) {
+	return file_sim_v1_simulator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetWalletRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type Wallet struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	AvailableBalance string                 `protobuf:"bytes,1,opt,name=available_balance,json=availableBalance,proto3" json:"available_balance,omitempty"`
+	InOrderBalance   string                 `protobuf:"bytes,2,opt,name=in_order_balance,json=inOrderBalance,proto3" json:"in_order_balance,omitempty"`
+	InAssetBalance   string                 `protobuf:"bytes,3,opt,name=in_asset_balance,json=inAssetBalance,proto3" json:"in_asset_balance,omitempty"`
+	TotalSpent       string                 `protobuf:"bytes,4,opt,name=total_spent,json=totalSpent,proto3" json:"total_spent,omitempty"`
+	Frozen           bool                   `protobuf:"varint,5,opt,name=frozen,proto3" json:"frozen,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Wallet) Reset() {
+	*x = Wallet{}
+	mi := &file_sim_v1_simulator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Wallet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Wallet) ProtoMessage() {}
+
+func (x *Wallet) ProtoReflect() protoreflect.Message {
+	mi := &file_sim_v1_simulator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Wallet.ProtoReflect.Descriptor instead.
+func (*Wallet) Descriptor() ([]byte, []int) {
+	return file_sim_v1_simulator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Wallet) GetAvailableBalance() string {
+	if x != nil {
+		return x.AvailableBalance
+	}
+	return ""
+}
+
+func (x *Wallet) GetInOrderBalance() string {
+	if x != nil {
+		return x.InOrderBalance
+	}
+	return ""
+}
+
+func (x *Wallet) GetInAssetBalance() string {
+	if x != nil {
+		return x.InAssetBalance
+	}
+	return ""
+}
+
+func (x *Wallet) GetTotalSpent() string {
+	if x != nil {
+		return x.TotalSpent
+	}
+	return ""
+}
+
+func (x *Wallet) GetFrozen() bool {
+	if x != nil {
+		return x.Frozen
+	}
+	return false
+}
+
+type ListOrdersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersRequest) Reset() {
+	*x = ListOrdersRequest{}
+	mi := &file_sim_v1_simulator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersRequest) ProtoMessage() {}
+
+func (x *ListOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sim_v1_simulator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersRequest.ProtoReflect.Descriptor instead.
+func (*ListOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_sim_v1_simulator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListOrdersRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type ListOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersResponse) Reset() {
+	*x = ListOrdersResponse{}
+	mi := &file_sim_v1_simulator_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersResponse) ProtoMessage() {}
+
+func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sim_v1_simulator_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersResponse.ProtoReflect.Descriptor instead.
+func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_sim_v1_simulator_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListOrdersResponse) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+type Order struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Side       OrderSide              `protobuf:"varint,2,opt,name=side,proto3,enum=investio.sim.v1.OrderSide" json:"side,omitempty"`
+	PortId     uint64                 `protobuf:"varint,3,opt,name=port_id,json=portId,proto3" json:"port_id,omitempty"`
+	FundId     string                 `protobuf:"bytes,4,opt,name=fund_id,json=fundId,proto3" json:"fund_id,omitempty"`
+	FundCode   string                 `protobuf:"bytes,5,opt,name=fund_code,json=fundCode,proto3" json:"fund_code,omitempty"`
+	CategoryId uint32                 `protobuf:"varint,6,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	Nav        string                 `protobuf:"bytes,7,opt,name=nav,proto3" json:"nav,omitempty"`
+	// Empty for orders placed without a NAV date
+	NavDate       string                 `protobuf:"bytes,8,opt,name=nav_date,json=navDate,proto3" json:"nav_date,omitempty"`
+	Amount        string                 `protobuf:"bytes,9,opt,name=amount,proto3" json:"amount,omitempty"`
+	Units         string                 `protobuf:"bytes,10,opt,name=units,proto3" json:"units,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_sim_v1_simulator_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_sim_v1_simulator_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_sim_v1_simulator_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Order) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Order) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_ORDER_SIDE_UNSPECIFIED
+}
+
+func (x *Order) GetPortId() uint64 {
+	if x != nil {
+		return x.PortId
+	}
+	return 0
+}
+
+func (x *Order) GetFundId() string {
+	if x != nil {
+		return x.FundId
+	}
+	return ""
+}
+
+func (x *Order) GetFundCode() string {
+	if x != nil {
+		return x.FundCode
+	}
+	return ""
+}
+
+func (x *Order) GetCategoryId() uint32 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *Order) GetNav() string {
+	if x != nil {
+		return x.Nav
+	}
+	return ""
+}
+
+func (x *Order) GetNavDate() string {
+	if x != nil {
+		return x.NavDate
+	}
+	return ""
+}
+
+func (x *Order) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *Order) GetUnits() string {
+	if x != nil {
+		return x.Units
+	}
+	return ""
+}
+
+func (x *Order) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type PlaceOrderRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	UserId   uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PortId   uint64                 `protobuf:"varint,2,opt,name=port_id,json=portId,proto3" json:"port_id,omitempty"`
+	FundCode string                 `protobuf:"bytes,3,opt,name=fund_code,json=fundCode,proto3" json:"fund_code,omitempty"`
+	// Optional; checked against the catalogue when given
+	FundId        string    `protobuf:"bytes,4,opt,name=fund_id,json=fundId,proto3" json:"fund_id,omitempty"`
+	Side          OrderSide `protobuf:"varint,5,opt,name=side,proto3,enum=investio.sim.v1.OrderSide" json:"side,omitempty"`
+	Amount        string    `protobuf:"bytes,6,opt,name=amount,proto3" json:"amount,omitempty"`
+	Units         string    `protobuf:"bytes,7,opt,name=units,proto3" json:"units,omitempty"`
+	Nav           string    `protobuf:"bytes,8,opt,name=nav,proto3" json:"nav,omitempty"`
+	NavDate       string    `protobuf:"bytes,9,opt,name=nav_date,json=navDate,proto3" json:"nav_date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceOrderRequest) Reset() {
+	*x = PlaceOrderRequest{}
+	mi := &file_sim_v1_simulator_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderRequest) ProtoMessage() {}
+
+func (x *PlaceOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sim_v1_simulator_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderRequest.ProtoReflect.Descriptor instead.
+func (*PlaceOrderRequest) Descriptor() ([]byte, []int) {
+	return file_sim_v1_simulator_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PlaceOrderRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetPortId() uint64 {
+	if x != nil {
+		return x.PortId
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetFundCode() string {
+	if x != nil {
+		return x.FundCode
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetFundId() string {
+	if x != nil {
+		return x.FundId
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_ORDER_SIDE_UNSPECIFIED
+}
+
+func (x *PlaceOrderRequest) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetUnits() string {
+	if x != nil {
+		return x.Units
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetNav() string {
+	if x != nil {
+		return x.Nav
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetNavDate() string {
+	if x != nil {
+		return x.NavDate
+	}
+	return ""
+}
+
+var File_sim_v1_simulator_proto protoreflect.FileDescriptor
+
+const file_sim_v1_simulator_proto_rawDesc = "" +
+	"\n" +
+	"\x16sim/v1/simulator.proto\x12\x0finvestio.sim.v1\x1a\x1fgoogle/protobuf/timestamp.proto\".\n" +
+	"\x13GetPortfolioRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\"\xae\x01\n" +
+	"\tPortfolio\x12\x17\n" +
+	"\aport_id\x18\x01 \x01(\x04R\x06portId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1f\n" +
+	"\vrealized_pl\x18\x03 \x01(\tR\n" +
+	"realizedPl\x12\x1d\n" +
+	"\n" +
+	"total_cost\x18\x04 \x01(\tR\ttotalCost\x124\n" +
+	"\bholdings\x18\x05 \x03(\v2\x18.investio.sim.v1.HoldingR\bholdings\"\xab\x01\n" +
+	"\aHolding\x12\x17\n" +
+	"\afund_id\x18\x01 \x01(\tR\x06fundId\x12\x1b\n" +
+	"\tfund_code\x18\x02 \x01(\tR\bfundCode\x12\x1f\n" +
+	"\vcategory_id\x18\x03 \x01(\rR\n" +
+	"categoryId\x12\x12\n" +
+	"\x04cost\x18\x04 \x01(\tR\x04cost\x12\x14\n" +
+	"\x05units\x18\x05 \x01(\tR\x05units\x12\x1f\n" +
+	"\vrealized_pl\x18\x06 \x01(\tR\n" +
+	"realizedPl\"+\n" +
+	"\x10GetWalletRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\"\xc2\x01\n" +
+	"\x06Wallet\x12+\n" +
+	"\x11available_balance\x18\x01 \x01(\tR\x10availableBalance\x12(\n" +
+	"\x10in_order_balance\x18\x02 \x01(\tR\x0einOrderBalance\x12(\n" +
+	"\x10in_asset_balance\x18\x03 \x01(\tR\x0einAssetBalance\x12\x1f\n" +
+	"\vtotal_spent\x18\x04 \x01(\tR\n" +
+	"totalSpent\x12\x16\n" +
+	"\x06frozen\x18\x05 \x01(\bR\x06frozen\",\n" +
+	"\x11ListOrdersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\"D\n" +
+	"\x12ListOrdersResponse\x12.\n" +
+	"\x06orders\x18\x01 \x03(\v2\x16.investio.sim.v1.OrderR\x06orders\"\xcd\x02\n" +
+	"\x05Order\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12.\n" +
+	"\x04side\x18\x02 \x01(\x0e2\x1a.investio.sim.v1.OrderSideR\x04side\x12\x17\n" +
+	"\aport_id\x18\x03 \x01(\x04R\x06portId\x12\x17\n" +
+	"\afund_id\x18\x04 \x01(\tR\x06fundId\x12\x1b\n" +
+	"\tfund_code\x18\x05 \x01(\tR\bfundCode\x12\x1f\n" +
+	"\vcategory_id\x18\x06 \x01(\rR\n" +
+	"categoryId\x12\x10\n" +
+	"\x03nav\x18\a \x01(\tR\x03nav\x12\x19\n" +
+	"\bnav_date\x18\b \x01(\tR\anavDate\x12\x16\n" +
+	"\x06amount\x18\t \x01(\tR\x06amount\x12\x14\n" +
+	"\x05units\x18\n" +
+	" \x01(\tR\x05units\x129\n" +
+	"\n" +
+	"created_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x86\x02\n" +
+	"\x11PlaceOrderRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12\x17\n" +
+	"\aport_id\x18\x02 \x01(\x04R\x06portId\x12\x1b\n" +
+	"\tfund_code\x18\x03 \x01(\tR\bfundCode\x12\x17\n" +
+	"\afund_id\x18\x04 \x01(\tR\x06fundId\x12.\n" +
+	"\x04side\x18\x05 \x01(\x0e2\x1a.investio.sim.v1.OrderSideR\x04side\x12\x16\n" +
+	"\x06amount\x18\x06 \x01(\tR\x06amount\x12\x14\n" +
+	"\x05units\x18\a \x01(\tR\x05units\x12\x10\n" +
+	"\x03nav\x18\b \x01(\tR\x03nav\x12\x19\n" +
+	"\bnav_date\x18\t \x01(\tR\anavDate*P\n" +
+	"\tOrderSide\x12\x1a\n" +
+	"\x16ORDER_SIDE_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eORDER_SIDE_BUY\x10\x01\x12\x13\n" +
+	"\x0fORDER_SIDE_SELL\x10\x022\xc7\x02\n" +
+	"\tSimulator\x12P\n" +
+	"\fGetPortfolio\x12$.investio.sim.v1.GetPortfolioRequest\x1a\x1a.investio.sim.v1.Portfolio\x12G\n" +
+	"\tGetWallet\x12!.investio.sim.v1.GetWalletRequest\x1a\x17.investio.sim.v1.Wallet\x12U\n" +
+	"\n" +
+	"ListOrders\x12\".investio.sim.v1.ListOrdersRequest\x1a#.investio.sim.v1.ListOrdersResponse\x12H\n" +
+	"\n" +
+	"PlaceOrder\x12\".investio.sim.v1.PlaceOrderRequest\x1a\x16.investio.sim.v1.OrderB8Z6gitlab.com/investio/backend/sim-api/proto/sim/v1;simv1b\x06proto3"
+
+var (
+	file_sim_v1_simulator_proto_rawDescOnce sync.Once
+	file_sim_v1_simulator_proto_rawDescData []byte
+)
+
+func file_sim_v1_simulator_proto_rawDescGZIP() []byte {
+	file_sim_v1_simulator_proto_rawDescOnce.Do(func() {
+		file_sim_v1_simulator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sim_v1_simulator_proto_rawDesc), len(file_sim_v1_simulator_proto_rawDesc)))
+	})
+	return file_sim_v1_simulator_proto_rawDescData
+}
+
+var file_sim_v1_simulator_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_sim_v1_simulator_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_sim_v1_simulator_proto_goTypes = []any{
+	(OrderSide)(0),                // 0: investio.sim.v1.OrderSide
+	(*GetPortfolioRequest)(nil),   // 1: investio.sim.v1.GetPortfolioRequest
+	(*Portfolio)(nil),             // 2: investio.sim.v1.Portfolio
+	(*Holding)(nil),               // 3: investio.sim.v1.Holding
+	(*GetWalletRequest)(nil),      // 4: investio.sim.v1.GetWalletRequest
+	(*Wallet)(nil),                // 5: investio.sim.v1.Wallet
+	(*ListOrdersRequest)(nil),     // 6: investio.sim.v1.ListOrdersRequest
+	(*ListOrdersResponse)(nil),    // 7: investio.sim.v1.ListOrdersResponse
+	(*Order)(nil),                 // 8: investio.sim.v1.Order
+	(*PlaceOrderRequest)(nil),     // 9: investio.sim.v1.PlaceOrderRequest
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_sim_v1_simulator_proto_depIdxs = []int32{
+	3,  // 0: investio.sim.v1.Portfolio.holdings:type_name -> investio.sim.v1.Holding
+	8,  // 1: investio.sim.v1.ListOrdersResponse.orders:type_name -> investio.sim.v1.Order
+	0,  // 2: investio.sim.v1.Order.side:type_name -> investio.sim.v1.OrderSide
+	10, // 3: investio.sim.v1.Order.created_at:type_name -> google.protobuf.Timestamp
+	0,  // 4: investio.sim.v1.PlaceOrderRequest.side:type_name -> investio.sim.v1.OrderSide
+	1,  // 5: investio.sim.v1.Simulator.GetPortfolio:input_type -> investio.sim.v1.GetPortfolioRequest
+	4,  // 6: investio.sim.v1.Simulator.GetWallet:input_type -> investio.sim.v1.GetWalletRequest
+	6,  // 7: investio.sim.v1.Simulator.ListOrders:input_type -> investio.sim.v1.ListOrdersRequest
+	9,  // 8: investio.sim.v1.Simulator.PlaceOrder:input_type -> investio.sim.v1.PlaceOrderRequest
+	2,  // 9: investio.sim.v1.Simulator.GetPortfolio:output_type -> investio.sim.v1.Portfolio
+	5,  // 10: investio.sim.v1.Simulator.GetWallet:output_type -> investio.sim.v1.Wallet
+	7,  // 11: investio.sim.v1.Simulator.ListOrders:output_type -> investio.sim.v1.ListOrdersResponse
+	8,  // 12: investio.sim.v1.Simulator.PlaceOrder:output_type -> investio.sim.v1.Order
+	9,  // [9:13] is the sub-list for method output_type
+	5,  // [5:9] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_sim_v1_simulator_proto_init() }
+func file_sim_v1_simulator_proto_init() {
+	if File_sim_v1_simulator_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sim_v1_simulator_proto_rawDesc), len(file_sim_v1_simulator_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sim_v1_simulator_proto_goTypes,
+		DependencyIndexes: file_sim_v1_simulator_proto_depIdxs,
+		EnumInfos:         file_sim_v1_simulator_proto_enumTypes,
+		MessageInfos:      file_sim_v1_simulator_proto_msgTypes,
+	}.Build()
+	File_sim_v1_simulator_proto = out.File
+	file_sim_v1_simulator_proto_goTypes = nil
+	file_sim_v1_simulator_proto_depIdxs = nil
+}
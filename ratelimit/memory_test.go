@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a time source the test moves by hand
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestMemoryLimiter() (*memoryLimiter, *clock) {
+	c := &clock{t: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)}
+	l := NewMemoryLimiter().(*memoryLimiter)
+	l.now = c.now
+	l.lastSweep = c.t
+	return l, c
+}
+
+// take calls Allow n times and returns how many were allowed and the last retryAfter
+func take(t *testing.T, l Limiter, key string, limit Limit, n int) (allowed int, retryAfter time.Duration) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		ok, wait, err := l.Allow(context.Background(), key, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			allowed++
+		}
+		retryAfter = wait
+	}
+	return
+}
+
+func TestMemoryBurst(t *testing.T) {
+	l, _ := newTestMemoryLimiter()
+	limit := Limit{Rate: 2, Burst: 3}
+
+	if allowed, wait := take(t, l, "order:7", limit, 4); allowed != 3 || wait != 500*time.Millisecond {
+		t.Errorf("4 requests at once: %d allowed, retry after %s; want 3 and 500ms", allowed, wait)
+	}
+	// Every key has its own bucket
+	if allowed, _ := take(t, l, "order:8", limit, 3); allowed != 3 {
+		t.Errorf("another key: %d of 3 allowed", allowed)
+	}
+	// A disabled limit lets everything through
+	if allowed, _ := take(t, l, "order:7", Limit{Rate: 0, Burst: 3}, 10); allowed != 10 {
+		t.Errorf("disabled limit: %d of 10 allowed", allowed)
+	}
+}
+
+func TestMemoryRefill(t *testing.T) {
+	l, c := newTestMemoryLimiter()
+	limit := Limit{Rate: 2, Burst: 3}
+	take(t, l, "order:7", limit, 3)
+
+	c.advance(250 * time.Millisecond)
+	if allowed, wait := take(t, l, "order:7", limit, 1); allowed != 0 || wait != 250*time.Millisecond {
+		t.Errorf("after half a token: allowed %d, retry after %s; want 0 and 250ms", allowed, wait)
+	}
+	c.advance(250 * time.Millisecond)
+	if allowed, _ := take(t, l, "order:7", limit, 2); allowed != 1 {
+		t.Errorf("after one token: %d of 2 allowed", allowed)
+	}
+
+	// A long pause refills no more than the burst
+	c.advance(time.Hour)
+	if allowed, _ := take(t, l, "order:7", limit, 5); allowed != 3 {
+		t.Errorf("after an hour: %d of 5 allowed, want the burst of 3", allowed)
+	}
+}
+
+func TestMemorySweepsFullBuckets(t *testing.T) {
+	l, c := newTestMemoryLimiter()
+	take(t, l, "read:7", Limit{Rate: 1, Burst: 2}, 1)
+	take(t, l, "order:7", Limit{Rate: 0.001, Burst: 2}, 1)
+
+	c.advance(sweepInterval)
+	take(t, l, "read:8", Limit{Rate: 1, Burst: 2}, 1)
+	if _, ok := l.buckets["read:7"]; ok {
+		t.Error("refilled bucket kept after the sweep")
+	}
+	if _, ok := l.buckets["order:7"]; !ok {
+		t.Error("bucket still refilling dropped by the sweep")
+	}
+}
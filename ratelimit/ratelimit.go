@@ -0,0 +1,41 @@
+// Package ratelimit implements token buckets shared by key, kept in process
+// memory or in a Redis-compatible server
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit lets Burst requests through at once and refills Rate tokens per second.
+// A Rate of zero or less disables limiting.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+type Limiter interface {
+	// Allow takes one token from the bucket of key. When the bucket is empty it
+	// returns false and how long until a token is available.
+	Allow(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// refill returns the tokens in a bucket after elapsed time, capped at the burst
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	if elapsed > 0 {
+		tokens += elapsed.Seconds() * limit.Rate
+	}
+	if max := float64(limit.Burst); tokens > max {
+		tokens = max
+	}
+	return tokens
+}
+
+// wait returns how long until the bucket holds a whole token
+func wait(tokens float64, limit Limit) time.Duration {
+	return time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+}
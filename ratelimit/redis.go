@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "sim:ratelimit:"
+
+// tokenBucket refills and takes from the bucket atomically on the server, using
+// the server clock so every replica sees the same time. It returns
+// {allowed, milliseconds to wait}. Idle buckets expire once they would be full.
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, wait}
+`)
+
+type redisLimiter struct {
+	client redis.Scripter
+}
+
+// NewRedisLimiter shares the buckets between replicas through Redis or a
+// compatible server such as Valkey or KeyDB
+func NewRedisLimiter(client redis.Scripter) Limiter {
+	return &redisLimiter{
+		client: client,
+	}
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error) {
+	if !limit.Enabled() {
+		return true, 0, nil
+	}
+
+	result, err := tokenBucket.Run(ctx, l.client, []string{redisKeyPrefix + key}, limit.Rate, limit.Burst).Int64Slice()
+	if err != nil {
+		return
+	}
+	allowed = result[0] == 1
+	retryAfter = time.Duration(result[1]) * time.Millisecond
+	return
+}
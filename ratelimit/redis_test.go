@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisLimiter(t *testing.T) (Limiter, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	server.SetTime(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisLimiter(client), server
+}
+
+func TestRedisBucket(t *testing.T) {
+	l, server := newTestRedisLimiter(t)
+	limit := Limit{Rate: 2, Burst: 3}
+
+	if allowed, wait := take(t, l, "order:7", limit, 4); allowed != 3 || wait != 500*time.Millisecond {
+		t.Errorf("4 requests at once: %d allowed, retry after %s; want 3 and 500ms", allowed, wait)
+	}
+	if allowed, _ := take(t, l, "order:8", limit, 3); allowed != 3 {
+		t.Errorf("another key: %d of 3 allowed", allowed)
+	}
+
+	// The script refills by the server clock
+	server.SetTime(time.Date(2026, 10, 16, 9, 0, 0, int(250*time.Millisecond), time.UTC))
+	if allowed, wait := take(t, l, "order:7", limit, 1); allowed != 0 || wait != 250*time.Millisecond {
+		t.Errorf("after half a token: allowed %d, retry after %s; want 0 and 250ms", allowed, wait)
+	}
+	server.SetTime(time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC))
+	if allowed, _ := take(t, l, "order:7", limit, 5); allowed != 3 {
+		t.Errorf("after an hour: %d of 5 allowed, want the burst of 3", allowed)
+	}
+
+	// The bucket expires once it would be full again
+	if ttl := server.TTL(redisKeyPrefix + "order:7"); ttl <= 0 || ttl > 3*time.Second {
+		t.Errorf("bucket TTL = %s, want the 1.5s refill plus a second", ttl)
+	}
+	server.FastForward(3 * time.Second)
+	if server.Exists(redisKeyPrefix + "order:7") {
+		t.Error("idle bucket did not expire")
+	}
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/v1/schema"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// adminToken signs an access token of an admin with the space-delimited scope
+func (a *testAPI) adminToken(userID uint, scope string) string {
+	return a.sign(schema.TokenClaims{
+		UserID:       userID,
+		IsAuthorized: true,
+		Roles:        []string{schema.RoleAdmin},
+		Scope:        scope,
+		Claims: &jwt.Claims{
+			IssuedAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+}
+
+type auditEntry struct {
+	AdminID       uint            `json:"admin_id"`
+	UserID        uint            `json:"uid"`
+	Action        string          `json:"action"`
+	Amount        decimal.Decimal `json:"amount"`
+	BalanceBefore decimal.Decimal `json:"balance_before"`
+	BalanceAfter  decimal.Decimal `json:"balance_after"`
+	Reason        string          `json:"reason"`
+}
+
+// auditLog returns the audit log of the user, newest first
+func (a *testAPI) auditLog(adminToken string, userID uint) (entries []auditEntry) {
+	a.t.Helper()
+
+	rec := a.do(http.MethodGet, fmt.Sprintf("/sim/admin/users/%d/audit", userID), adminToken, nil)
+	if rec.Code != http.StatusOK {
+		a.t.Fatalf("get audit log: %d %s", rec.Code, rec.Body)
+	}
+	decode(a.t, rec, &entries)
+	return
+}
+
+func TestAdminAuthorization(t *testing.T) {
+	api := newTestAPI(t)
+	api.openAccount(api.token(7, time.Hour))
+
+	user := api.token(9, time.Hour)
+	reader := api.adminToken(1, "")
+	writer := api.adminToken(1, "openid "+schema.ScopeAdminWrite)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		// wantStatus by token: user, admin without the write scope, admin with it
+		wantStatus [3]int
+	}{
+		{"read wallet", http.MethodGet, "/sim/admin/users/7/wallet", nil,
+			[3]int{http.StatusForbidden, http.StatusOK, http.StatusOK}},
+		{"read audit log", http.MethodGet, "/sim/admin/users/7/audit", nil,
+			[3]int{http.StatusForbidden, http.StatusOK, http.StatusOK}},
+		{"adjust balance", http.MethodPost, "/sim/admin/users/7/wallet/adjustments", map[string]string{"amount": "10", "reason": "test"},
+			[3]int{http.StatusForbidden, http.StatusForbidden, http.StatusOK}},
+		{"freeze", http.MethodPost, "/sim/admin/users/7/freeze", map[string]string{"reason": "test"},
+			[3]int{http.StatusForbidden, http.StatusForbidden, http.StatusOK}},
+		{"unfreeze", http.MethodPost, "/sim/admin/users/7/unfreeze", map[string]string{"reason": "test"},
+			[3]int{http.StatusForbidden, http.StatusForbidden, http.StatusOK}},
+	}
+
+	for _, tt := range tests {
+		for i, token := range []string{user, reader, writer} {
+			rec := api.do(tt.method, tt.path, token, tt.body)
+			if rec.Code != tt.wantStatus[i] {
+				t.Errorf("%s with token %d = %d %s, want %d", tt.name, i, rec.Code, rec.Body, tt.wantStatus[i])
+			}
+			if rec.Code == http.StatusForbidden && errorCode(rec) != service.ErrCodeNotAuthorized {
+				t.Errorf("%s with token %d: code %q, want %s", tt.name, i, errorCode(rec), service.ErrCodeNotAuthorized)
+			}
+		}
+	}
+}
+
+func TestAdminAdjustBalance(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	api.openAccount(token)
+	admin := api.adminToken(1, schema.ScopeAdminWrite)
+	adjust := func(amount, reason string) int {
+		body := map[string]string{"amount": amount, "reason": reason}
+		rec := api.do(http.MethodPost, "/sim/admin/users/7/wallet/adjustments", admin, body)
+		return rec.Code
+	}
+
+	if code := adjust("250.5", "goodwill credit"); code != http.StatusOK {
+		t.Fatalf("credit: %d", code)
+	}
+	if code := adjust("-50", "correction"); code != http.StatusOK {
+		t.Fatalf("debit: %d", code)
+	}
+	// Rejected adjustments leave no trace
+	for _, rejected := range [][2]string{{"-5000", "overdraw"}, {"0", "nothing"}, {"10", ""}} {
+		if code := adjust(rejected[0], rejected[1]); code != http.StatusBadRequest {
+			t.Errorf("adjustment of %s with reason %q = %d, want 400", rejected[0], rejected[1], code)
+		}
+	}
+
+	if wallet := api.wallet(token); wallet.Available.String() != "1200.5" {
+		t.Errorf("available = %s, want 1200.5", wallet.Available)
+	}
+
+	entries := api.auditLog(admin, 7)
+	want := []auditEntry{
+		{AdminID: 1, UserID: 7, Action: "adjust_balance", Amount: decimal.RequireFromString("-50"),
+			BalanceBefore: decimal.RequireFromString("1250.5"), BalanceAfter: decimal.RequireFromString("1200.5"), Reason: "correction"},
+		{AdminID: 1, UserID: 7, Action: "adjust_balance", Amount: decimal.RequireFromString("250.5"),
+			BalanceBefore: decimal.RequireFromString("1000"), BalanceAfter: decimal.RequireFromString("1250.5"), Reason: "goodwill credit"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("audit log has %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, e := range entries {
+		w := want[i]
+		if e.AdminID != w.AdminID || e.UserID != w.UserID || e.Action != w.Action || e.Reason != w.Reason ||
+			!e.Amount.Equal(w.Amount) || !e.BalanceBefore.Equal(w.BalanceBefore) || !e.BalanceAfter.Equal(w.BalanceAfter) {
+			t.Errorf("audit entry %d = %+v, want %+v", i, e, w)
+		}
+	}
+}
+
+func TestFrozenAccount(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	portID := api.openAccount(token)
+	admin := api.adminToken(1, schema.ScopeAdminWrite)
+	if rec := api.do(http.MethodPost, "/sim/v1/port/buy", token, order(portID, testFundCode, "100", "10")); rec.Code != http.StatusOK {
+		t.Fatalf("buy: %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := api.do(http.MethodPost, "/sim/admin/users/7/freeze", admin, map[string]string{"reason": "suspicious activity"}); rec.Code != http.StatusOK {
+		t.Fatalf("freeze: %d %s", rec.Code, rec.Body)
+	}
+	for _, path := range []string{"/sim/v1/port/buy", "/sim/v1/port/sell"} {
+		rec := api.do(http.MethodPost, path, token, order(portID, testFundCode, "50", "5"))
+		if rec.Code != http.StatusForbidden || errorCode(rec) != service.ErrCodeAccountFrozen {
+			t.Errorf("%s while frozen = %d %s, want 403 %s", path, rec.Code, rec.Body, service.ErrCodeAccountFrozen)
+		}
+	}
+	if wallet := api.wallet(token); wallet.Available.String() != "900" || !api.units(token, testFundCode).Equal(decimal.NewFromInt(10)) {
+		t.Errorf("frozen account changed: %s available", wallet.Available)
+	}
+
+	// Only admins see the flag, the v1 wallet keeps its contract
+	var wallet map[string]interface{}
+	decode(t, api.do(http.MethodGet, "/sim/admin/users/7/wallet", admin, nil), &wallet)
+	if wallet["is_frozen"] != true {
+		t.Errorf("admin wallet = %v, want is_frozen true", wallet)
+	}
+	wallet = nil
+	decode(t, api.do(http.MethodGet, "/sim/v1/wallet", token, nil), &wallet)
+	if _, ok := wallet["is_frozen"]; ok {
+		t.Errorf("v1 wallet = %v, want no is_frozen", wallet)
+	}
+
+	if rec := api.do(http.MethodPost, "/sim/admin/users/7/unfreeze", admin, map[string]string{"reason": "cleared"}); rec.Code != http.StatusOK {
+		t.Fatalf("unfreeze: %d %s", rec.Code, rec.Body)
+	}
+	if rec := api.do(http.MethodPost, "/sim/v1/port/sell", token, order(portID, testFundCode, "50", "5")); rec.Code != http.StatusOK {
+		t.Errorf("sell after unfreezing = %d %s", rec.Code, rec.Body)
+	}
+
+	entries := api.auditLog(admin, 7)
+	if len(entries) != 2 || entries[0].Action != "unfreeze" || entries[1].Action != "freeze" || entries[1].Reason != "suspicious activity" {
+		t.Errorf("audit log = %+v, want the freeze then the unfreeze", entries)
+	}
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"gitlab.com/investio/backend/sim-api/logging"
+	simv1 "gitlab.com/investio/backend/sim-api/proto/sim/v1"
+	"gitlab.com/investio/backend/sim-api/v1/middleware"
+	"gitlab.com/investio/backend/sim-api/v1/schema"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain names the simulator in the ErrorInfo of gRPC failures
+const errorDomain = "sim-api"
+
+// Metadata keys, lower case as gRPC sends them
+const (
+	authorizationKey = "authorization"
+	requestIDKey     = "x-request-id"
+)
+
+// scopesByMethod lists the scope a service token needs on top of the service role
+var scopesByMethod = map[string]string{
+	simv1.Simulator_PlaceOrder_FullMethodName: schema.ScopeOrdersWrite,
+}
+
+var grpcCodeByCode = map[service.ErrorCode]codes.Code{
+	service.ErrCodeInternal:            codes.Internal,
+	service.ErrCodeDatabase:            codes.Unavailable,
+	service.ErrCodeInvalidRequest:      codes.InvalidArgument,
+	service.ErrCodeInvalidAmount:       codes.InvalidArgument,
+	service.ErrCodeInvalidUnit:         codes.InvalidArgument,
+	service.ErrCodeTokenMissing:        codes.Unauthenticated,
+	service.ErrCodeTokenInvalid:        codes.Unauthenticated,
+	service.ErrCodeTokenExpired:        codes.Unauthenticated,
+	service.ErrCodeTokenMalformed:      codes.Unauthenticated,
+	service.ErrCodeTokenNotYetValid:    codes.Unauthenticated,
+	service.ErrCodeTokenIssuedInFuture: codes.Unauthenticated,
+	service.ErrCodeTokenIssuer:         codes.Unauthenticated,
+	service.ErrCodeTokenAudience:       codes.Unauthenticated,
+	service.ErrCodeRefreshToken:        codes.Unauthenticated,
+	service.ErrCodeNotAuthorized:       codes.PermissionDenied,
+	service.ErrCodePortNotFound:        codes.NotFound,
+	service.ErrCodePortMismatch:        codes.InvalidArgument,
+	service.ErrCodeWalletNotFound:      codes.NotFound,
+	service.ErrCodeAccountFrozen:       codes.FailedPrecondition,
+	service.ErrCodeHoldingNotFound:     codes.FailedPrecondition,
+	service.ErrCodeInsufficientBalance: codes.FailedPrecondition,
+	service.ErrCodeInsufficientAsset:   codes.FailedPrecondition,
+	service.ErrCodeInsufficientCost:    codes.FailedPrecondition,
+	service.ErrCodeInsufficientUnits:   codes.FailedPrecondition,
+	service.ErrCodeFundCodeRequired:    codes.InvalidArgument,
+	service.ErrCodeFundNotFound:        codes.NotFound,
+	service.ErrCodeFundMismatch:        codes.InvalidArgument,
+	service.ErrCodeFundInactive:        codes.FailedPrecondition,
+	service.ErrCodeReversalFailed:      codes.Internal,
+	service.ErrCodeConcurrentUpdate:    codes.Aborted,
+	service.ErrCodeReasonRequired:      codes.InvalidArgument,
+	service.ErrCodeRateLimited:         codes.ResourceExhausted,
+}
+
+// newGRPCServer serves the Simulator API on svc to callers with a service token
+func newGRPCServer(svc services) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			logGRPCRequest(),
+			recoverGRPC(),
+			authenticateService(svc.auth),
+		),
+	)
+	simv1.RegisterSimulatorServer(srv, &simulatorServer{svc: svc})
+	return srv
+}
+
+// grpcError converts a service error to a gRPC status carrying its code in an ErrorInfo
+func grpcError(err error) error {
+	code := service.CodeOf(err)
+	grpcCode, ok := grpcCodeByCode[code]
+	if !ok {
+		grpcCode = codes.Internal
+	}
+
+	st := status.New(grpcCode, code.Message("en"))
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: string(code), Domain: errorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// logGRPCRequest adds the request ID from the x-request-id metadata, or a new one,
+// to the context for logging.FromContext and writes one access log line per call
+func logGRPCRequest() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		id := firstMetadata(ctx, requestIDKey)
+		if !middleware.ValidRequestID(id) {
+			id = middleware.NewRequestID()
+		}
+		ctx = logging.WithRequest(ctx, id, info.FullMethod)
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		entry := logging.FromContext(ctx).WithFields(map[string]interface{}{
+			"grpc_code":  code.String(),
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		})
+		switch code {
+		case codes.Internal, codes.Unavailable, codes.Unknown:
+			entry.WithError(err).Error("gRPC request")
+		default:
+			entry.Info("gRPC request")
+		}
+		return resp, err
+	}
+}
+
+// recoverGRPC turns a panic in a handler into an INTERNAL_ERROR instead of a crash
+func recoverGRPC() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logging.FromContext(ctx).Errorf("Panic in %s: %v\n%s", info.FullMethod, r, debug.Stack())
+				err = grpcError(service.NewError(service.ErrCodeInternal, nil))
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// authenticateService allows calls with a valid service token, "Bearer <JWT>" in the
+// authorization metadata, that is authorized, like on HTTP, and has the service role
+// and the scope of the method
+func authenticateService(auth service.AuthService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		raw := firstMetadata(ctx, authorizationKey)
+		if len(raw) > len("Bearer ") && strings.EqualFold(raw[:len("Bearer ")], "Bearer ") {
+			raw = raw[len("Bearer "):]
+		} else {
+			raw = ""
+		}
+		if raw == "" {
+			return nil, grpcError(service.NewError(service.ErrCodeTokenMissing, nil))
+		}
+
+		claims, err := auth.ValidateToken(raw)
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		if !claims.IsAuthorized || !claims.HasRole(schema.RoleService) {
+			return nil, grpcError(service.NewError(service.ErrCodeNotAuthorized, nil))
+		}
+		if scope, ok := scopesByMethod[info.FullMethod]; ok && !claims.HasScope(scope) {
+			return nil, grpcError(service.NewError(service.ErrCodeNotAuthorized, nil))
+		}
+		return handler(ctx, req)
+	}
+}
+
+// firstMetadata returns the first value of the incoming metadata key, or ""
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	simv1 "gitlab.com/investio/backend/sim-api/proto/sim/v1"
+	"gitlab.com/investio/backend/sim-api/v1/schema"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// grpcClient serves the gRPC API of the test server in memory and connects to it
+func (a *testAPI) grpcClient() simv1.SimulatorClient {
+	a.t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = a.server.grpc.Serve(listener) }()
+	a.t.Cleanup(a.server.grpc.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	a.t.Cleanup(func() { conn.Close() })
+	return simv1.NewSimulatorClient(conn)
+}
+
+// serviceToken signs a token of another backend service, with the service role and scope
+func (a *testAPI) serviceToken(scope string, ttl time.Duration) string {
+	return a.sign(schema.TokenClaims{
+		IsAuthorized: true,
+		Roles:        []string{schema.RoleService},
+		Scope:        scope,
+		Claims: &jwt.Claims{
+			Subject:  "notification-api",
+			IssuedAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	})
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// grpcErrorCode returns the gRPC code of err and the service error code in its ErrorInfo
+func grpcErrorCode(err error) (codes.Code, service.ErrorCode) {
+	st := status.Convert(err)
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return st.Code(), service.ErrorCode(info.Reason)
+		}
+	}
+	return st.Code(), ""
+}
+
+func TestGRPCAuthentication(t *testing.T) {
+	api := newTestAPI(t)
+	client := api.grpcClient()
+	api.openAccount(api.token(7, time.Hour))
+
+	order := &simv1.PlaceOrderRequest{UserId: 7, Side: simv1.OrderSide_ORDER_SIDE_BUY}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		call     func(ctx context.Context) error
+		wantCode codes.Code
+		wantErr  service.ErrorCode
+	}{
+		{
+			name:     "no token",
+			ctx:      context.Background(),
+			wantCode: codes.Unauthenticated,
+			wantErr:  service.ErrCodeTokenMissing,
+		},
+		{
+			name:     "not a bearer token",
+			ctx:      metadata.AppendToOutgoingContext(context.Background(), "authorization", api.serviceToken("", time.Hour)),
+			wantCode: codes.Unauthenticated,
+			wantErr:  service.ErrCodeTokenMissing,
+		},
+		{
+			name:     "expired service token",
+			ctx:      withToken(api.serviceToken("", -time.Hour)),
+			wantCode: codes.Unauthenticated,
+			wantErr:  service.ErrCodeTokenExpired,
+		},
+		{
+			name:     "user token",
+			ctx:      withToken(api.token(7, time.Hour)),
+			wantCode: codes.PermissionDenied,
+			wantErr:  service.ErrCodeNotAuthorized,
+		},
+		{
+			name: "unauthorized service token",
+			ctx: withToken(api.sign(schema.TokenClaims{
+				Roles: []string{schema.RoleService},
+				Scope: schema.ScopeOrdersWrite,
+				Claims: &jwt.Claims{
+					Subject:  "notification-api",
+					IssuedAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+					Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				},
+			})),
+			wantCode: codes.PermissionDenied,
+			wantErr:  service.ErrCodeNotAuthorized,
+		},
+		{
+			name:     "service token",
+			ctx:      withToken(api.serviceToken("", time.Hour)),
+			wantCode: codes.OK,
+		},
+		{
+			name: "order without the orders scope",
+			ctx:  withToken(api.serviceToken("", time.Hour)),
+			call: func(ctx context.Context) error {
+				_, err := client.PlaceOrder(ctx, order)
+				return err
+			},
+			wantCode: codes.PermissionDenied,
+			wantErr:  service.ErrCodeNotAuthorized,
+		},
+		{
+			name: "order with the orders scope reaches the handler",
+			ctx:  withToken(api.serviceToken(schema.ScopeOrdersWrite, time.Hour)),
+			call: func(ctx context.Context) error {
+				_, err := client.PlaceOrder(ctx, order)
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+			wantErr:  service.ErrCodeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := tt.call
+			if call == nil {
+				call = func(ctx context.Context) error {
+					_, err := client.GetWallet(ctx, &simv1.GetWalletRequest{UserId: 7})
+					return err
+				}
+			}
+			code, errCode := grpcErrorCode(call(tt.ctx))
+			if code != tt.wantCode || errCode != tt.wantErr {
+				t.Errorf("got %s %q, want %s %q", code, errCode, tt.wantCode, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGRPCOrders(t *testing.T) {
+	api := newTestAPI(t)
+	client := api.grpcClient()
+	portID := api.openAccount(api.token(7, time.Hour))
+	ctx := withToken(api.serviceToken(schema.ScopeOrdersWrite, time.Hour))
+
+	place := func(side simv1.OrderSide, amount, units string) (*simv1.Order, error) {
+		return client.PlaceOrder(ctx, &simv1.PlaceOrderRequest{
+			UserId:   7,
+			PortId:   uint64(portID),
+			FundCode: testFundCode,
+			Side:     side,
+			Amount:   amount,
+			Units:    units,
+			Nav:      "10",
+			NavDate:  "2026-10-16",
+		})
+	}
+
+	bought, err := place(simv1.OrderSide_ORDER_SIDE_BUY, "300", "30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bought.GetId() == 0 || bought.GetFundId() != testFundID || bought.GetAmount() != "300.00" ||
+		bought.GetUnits() != "30.00000000" || bought.GetNavDate() != "2026-10-16" || !bought.GetCreatedAt().IsValid() {
+		t.Errorf("bought %v", bought)
+	}
+	if _, err := place(simv1.OrderSide_ORDER_SIDE_SELL, "100", "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name     string
+		side     simv1.OrderSide
+		amount   string
+		units    string
+		wantCode codes.Code
+		wantErr  service.ErrorCode
+	}{
+		{"no side", simv1.OrderSide_ORDER_SIDE_UNSPECIFIED, "10", "1", codes.InvalidArgument, service.ErrCodeInvalidRequest},
+		{"amount not a decimal", simv1.OrderSide_ORDER_SIDE_BUY, "ten", "1", codes.InvalidArgument, service.ErrCodeInvalidRequest},
+		{"zero amount", simv1.OrderSide_ORDER_SIDE_BUY, "0", "1", codes.InvalidArgument, service.ErrCodeInvalidAmount},
+		{"more than the balance", simv1.OrderSide_ORDER_SIDE_BUY, "5000", "500", codes.FailedPrecondition, service.ErrCodeInsufficientBalance},
+	} {
+		_, err := place(c.side, c.amount, c.units)
+		if code, errCode := grpcErrorCode(err); code != c.wantCode || errCode != c.wantErr {
+			t.Errorf("%s: got %s %q, want %s %q", c.name, code, errCode, c.wantCode, c.wantErr)
+		}
+	}
+
+	portfolio, err := client.GetPortfolio(ctx, &simv1.GetPortfolioRequest{UserId: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	holdings := portfolio.GetHoldings()
+	if portfolio.GetPortId() != uint64(portID) || len(holdings) != 1 ||
+		holdings[0].GetFundCode() != testFundCode || holdings[0].GetUnits() != "20.00000000" || holdings[0].GetCost() != "200.00" {
+		t.Errorf("portfolio = %v", portfolio)
+	}
+
+	wallet, err := client.GetWallet(ctx, &simv1.GetWalletRequest{UserId: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallet.GetAvailableBalance() != "800.00" || wallet.GetInAssetBalance() != "200.00" {
+		t.Errorf("wallet = %v", wallet)
+	}
+
+	orders, err := client.ListOrders(ctx, &simv1.ListOrdersRequest{UserId: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders.GetOrders()) != 2 {
+		t.Errorf("got %d orders, want 2", len(orders.GetOrders()))
+	}
+
+	// Users without an account are not opened one by other services
+	_, err = client.GetPortfolio(ctx, &simv1.GetPortfolioRequest{UserId: 8})
+	if code, errCode := grpcErrorCode(err); code != codes.NotFound || errCode != service.ErrCodePortNotFound {
+		t.Errorf("portfolio of a new user: got %s %q, want NotFound PORT_NOT_FOUND", code, errCode)
+	}
+	_, err = client.GetWallet(ctx, &simv1.GetWalletRequest{})
+	if code, errCode := grpcErrorCode(err); code != codes.InvalidArgument || errCode != service.ErrCodeInvalidRequest {
+		t.Errorf("wallet without a user: got %s %q, want InvalidArgument INVALID_REQUEST", code, errCode)
+	}
+}
@@ -0,0 +1,354 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/investio/backend/sim-api/config"
+	"gitlab.com/investio/backend/sim-api/db"
+	"gitlab.com/investio/backend/sim-api/ratelimit"
+	"gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+	"gitlab.com/investio/backend/sim-api/v1/schema"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Funds in the catalogue of every test API
+const (
+	testFundCode     = "SIM-SET50"
+	testFundID       = "M0001_2563"
+	inactiveFundCode = "SIM-CLOSED"
+)
+
+// testStartBalance is the balance of a new wallet in the test API
+var testStartBalance = decimal.NewFromInt(1000)
+
+var errInjected = errors.New("injected failure")
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+// testAPI is the server on a private in-memory SQLite database,
+// accepting tokens signed by a key generated for the test
+type testAPI struct {
+	t      *testing.T
+	server *Server
+	repos  repository.Repositories
+	signer jose.Signer
+	faults *faults
+}
+
+// testKeySet verifies tokens with a single ed25519 key
+type testKeySet struct {
+	key ed25519.PublicKey
+}
+
+func (ks testKeySet) Key(kid string) (interface{}, error) {
+	return ks.key, nil
+}
+
+// newTestAPI builds the test server; configure adjusts the test settings
+func newTestAPI(t *testing.T, configure ...func(cfg *config.Config)) *testAPI {
+	t.Helper()
+
+	gormDB, err := gorm.Open(sqlite.Open("file::memory:?_pragma=foreign_keys(1)"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The in-memory database lives as long as its only connection
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := db.NewMigrator(gormDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.EdDSA, Key: private}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.Wallet.StartBalance = testStartBalance
+	cfg.RateLimit.Read.Rate = 0
+	cfg.RateLimit.Order.Rate = 0
+	cfg.Server.GRPCPort = 0
+	cfg.Server.MetricsPort = 0
+	for _, c := range configure {
+		c(&cfg)
+	}
+
+	f := &faults{}
+	repos := repository.NewGormRepositories(gormDB)
+	repos.Transactions = faultyTransactions{repos.Transactions, f}
+	repos.Wallets = faultyWallets{repos.Wallets, f}
+	repos.PortFunds = faultyPortFunds{repos.PortFunds, f}
+
+	err = repos.Funds.Upsert(context.Background(), []model.Fund{
+		{ID: testFundID, Code: testFundCode, NameEn: "Simulated SET50 Index Fund", BcatID: 1, Status: model.FundStatusActive},
+		{ID: "M0002_2563", Code: inactiveFundCode, NameEn: "Simulated Closed Fund", BcatID: 2, Status: model.FundStatusInactive},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := New(cfg, Deps{
+		Repos:       repos,
+		Transactor:  repository.NewGormTransactor(gormDB),
+		Keys:        testKeySet{key: public},
+		Health:      service.NewHealthService(sqlDB, migrator),
+		RateLimiter: faultyLimiter{ratelimit.NewMemoryLimiter(), f},
+	})
+
+	return &testAPI{
+		t:      t,
+		server: srv,
+		repos:  repos,
+		signer: signer,
+		faults: f,
+	}
+}
+
+// token signs an access token for the user that expires after ttl; a negative ttl makes it expired
+func (a *testAPI) token(userID uint, ttl time.Duration) string {
+	return a.sign(schema.TokenClaims{
+		UserID:       userID,
+		IsAuthorized: true,
+		Claims: &jwt.Claims{
+			IssuedAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	})
+}
+
+func (a *testAPI) sign(claims schema.TokenClaims) string {
+	a.t.Helper()
+
+	raw, err := jwt.Signed(a.signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return raw
+}
+
+// do sends a request with the token, if any, and body encoded as JSON, if not nil.
+// header lists extra header names and values, in pairs.
+func (a *testAPI) do(method, path, token string, body interface{}, header ...string) *httptest.ResponseRecorder {
+	a.t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			a.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	checkResponse(a.t, req, rec)
+	return rec
+}
+
+// openAccount creates the wallet and port of the user, like the app does on first
+// visit, and returns the port ID
+func (a *testAPI) openAccount(token string) uint {
+	a.t.Helper()
+
+	if rec := a.do(http.MethodGet, "/sim/v1/wallet", token, nil); rec.Code != http.StatusOK {
+		a.t.Fatalf("open wallet: %d %s", rec.Code, rec.Body)
+	}
+
+	rec := a.do(http.MethodGet, "/sim/v1/port", token, nil)
+	if rec.Code != http.StatusOK {
+		a.t.Fatalf("open port: %d %s", rec.Code, rec.Body)
+	}
+	var port struct {
+		PortID uint `json:"port_id"`
+	}
+	decode(a.t, rec, &port)
+	return port.PortID
+}
+
+// wallet returns the wallet of the user
+func (a *testAPI) wallet(token string) (wallet struct {
+	Available decimal.Decimal `json:"avalible_bal"`
+	InAsset   decimal.Decimal `json:"inasset_bal"`
+}) {
+	a.t.Helper()
+
+	rec := a.do(http.MethodGet, "/sim/v1/wallet", token, nil)
+	if rec.Code != http.StatusOK {
+		a.t.Fatalf("get wallet: %d %s", rec.Code, rec.Body)
+	}
+	decode(a.t, rec, &wallet)
+	return
+}
+
+// units returns the units of the fund held in the user's port, zero when not held
+func (a *testAPI) units(token, fundCode string) decimal.Decimal {
+	a.t.Helper()
+
+	rec := a.do(http.MethodGet, "/sim/v1/port", token, nil)
+	if rec.Code != http.StatusOK {
+		a.t.Fatalf("get port: %d %s", rec.Code, rec.Body)
+	}
+	var port struct {
+		Funds []model.PortFund `json:"funds"`
+	}
+	decode(a.t, rec, &port)
+	for _, f := range port.Funds {
+		if f.FundCode == fundCode {
+			return f.Unit
+		}
+	}
+	return decimal.Zero
+}
+
+func order(portID uint, fundCode, amount, unit string) dto.OrderRequest {
+	return dto.OrderRequest{
+		DataDate: model.Date(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)),
+		PortID:   portID,
+		FundCode: fundCode,
+		Amount:   decimal.RequireFromString(amount),
+		Unit:     decimal.RequireFromString(unit),
+		NAV:      decimal.NewFromInt(10),
+	}
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+}
+
+// errorCode returns the code of an ErrorResponse body, or "" for any other body
+func errorCode(rec *httptest.ResponseRecorder) service.ErrorCode {
+	var body struct {
+		Code service.ErrorCode `json:"code"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	return body.Code
+}
+
+// faults makes repository and rate limiter calls fail, to drive the reversal and fallback
+// paths. Saves fail from the n-th call on, counted from when the faults are set; zero values
+// never fail.
+type faults struct {
+	orderWrite      bool
+	walletSaveFrom  int
+	holdingSaveFrom int
+	walletSaves     int
+	holdingSaves    int
+	limiterDown     bool
+	// walletConflicts saves of a wallet fail with ErrConflict, as if other orders kept winning the race
+	walletConflicts int
+	// beforeOrderWrite, when set, runs as an order is written, to hold a request mid-way
+	beforeOrderWrite func()
+}
+
+type faultyTransactions struct {
+	repository.TransactionRepository
+	f *faults
+}
+
+func (r faultyTransactions) Create(ctx context.Context, tran *model.Transaction) error {
+	if r.f.beforeOrderWrite != nil {
+		r.f.beforeOrderWrite()
+	}
+	if r.f.orderWrite {
+		return errInjected
+	}
+	return r.TransactionRepository.Create(ctx, tran)
+}
+
+type faultyWallets struct {
+	repository.WalletRepository
+	f *faults
+}
+
+func (r faultyWallets) Save(ctx context.Context, wallet *model.Wallet) error {
+	r.f.walletSaves++
+	if r.f.walletConflicts > 0 {
+		r.f.walletConflicts--
+		return repository.ErrConflict
+	}
+	if r.f.walletSaveFrom > 0 && r.f.walletSaves >= r.f.walletSaveFrom {
+		return errInjected
+	}
+	return r.WalletRepository.Save(ctx, wallet)
+}
+
+type faultyPortFunds struct {
+	repository.PortFundRepository
+	f *faults
+}
+
+func (r faultyPortFunds) Save(ctx context.Context, fund *model.PortFund) error {
+	r.f.holdingSaves++
+	if r.f.holdingSaveFrom > 0 && r.f.holdingSaves >= r.f.holdingSaveFrom {
+		return errInjected
+	}
+	return r.PortFundRepository.Save(ctx, fund)
+}
+
+type faultyLimiter struct {
+	ratelimit.Limiter
+	f *faults
+}
+
+func (l faultyLimiter) Allow(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+	if l.f.limiterDown {
+		return false, 0, errInjected
+	}
+	return l.Limiter.Allow(ctx, key, limit)
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+func TestIdempotentOrders(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		// other is the path of the other side, where the key was not used
+		other         string
+		buyFirst      bool
+		wantAvailable string
+		wantUnits     string
+	}{
+		{
+			name: "buy", path: "/sim/v1/port/buy", other: "/sim/v1/port/sell",
+			wantAvailable: "900", wantUnits: "10",
+		},
+		{
+			name: "sell", path: "/sim/v1/port/sell", other: "/sim/v1/port/buy", buyFirst: true,
+			wantAvailable: "600", wantUnits: "40",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			token := api.token(7, time.Hour)
+			portID := api.openAccount(token)
+			if tc.buyFirst {
+				if rec := api.do(http.MethodPost, "/sim/v1/port/buy", token, order(portID, testFundCode, "500", "50")); rec.Code != http.StatusOK {
+					t.Fatalf("setup buy: %d %s", rec.Code, rec.Body)
+				}
+			}
+			send := func(path, amount, unit string) *httptest.ResponseRecorder {
+				return api.do(http.MethodPost, path, token, order(portID, testFundCode, amount, unit), "Idempotency-Key", "order-1")
+			}
+
+			first := send(tc.path, "100", "10")
+			if first.Code != http.StatusOK || first.Header().Get("Idempotent-Replayed") != "" {
+				t.Fatalf("first request = %d replayed %q: %s", first.Code, first.Header().Get("Idempotent-Replayed"), first.Body)
+			}
+
+			// The same key and body get the stored response
+			second := send(tc.path, "100", "10")
+			if second.Code != http.StatusOK || second.Header().Get("Idempotent-Replayed") != "true" {
+				t.Errorf("retry = %d replayed %q, want 200 replayed", second.Code, second.Header().Get("Idempotent-Replayed"))
+			}
+			if second.Body.String() != first.Body.String() {
+				t.Errorf("retry body = %s, want the first %s", second.Body, first.Body)
+			}
+
+			// The same key with another body, or on another route, is refused
+			for _, rec := range []*httptest.ResponseRecorder{send(tc.path, "200", "20"), send(tc.other, "100", "10")} {
+				if rec.Code != http.StatusUnprocessableEntity || errorCode(rec) != service.ErrCodeIdempotencyKeyReused {
+					t.Errorf("reused key = %d %s, want 422 %s", rec.Code, rec.Body, service.ErrCodeIdempotencyKeyReused)
+				}
+			}
+
+			// The wallet and the holding changed once
+			if wallet := api.wallet(token); wallet.Available.String() != tc.wantAvailable {
+				t.Errorf("available = %s, want %s", wallet.Available, tc.wantAvailable)
+			}
+			if units := api.units(token, testFundCode); units.String() != tc.wantUnits {
+				t.Errorf("units = %s, want %s", units, tc.wantUnits)
+			}
+		})
+	}
+}
+
+func TestConcurrentIdempotentOrder(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	portID := api.openAccount(token)
+	send := func() *httptest.ResponseRecorder {
+		return api.do(http.MethodPost, "/sim/v1/port/buy", token, order(portID, testFundCode, "100", "10"), "Idempotency-Key", "order-1")
+	}
+
+	// Hold the first request as it writes the order
+	writing, resume := make(chan struct{}), make(chan struct{})
+	api.faults.beforeOrderWrite = func() {
+		close(writing)
+		<-resume
+	}
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send() }()
+	<-writing
+
+	if rec := send(); rec.Code != http.StatusConflict || errorCode(rec) != service.ErrCodeIdempotencyKeyInProgress {
+		t.Errorf("duplicate during the first request = %d %s, want 409 %s", rec.Code, rec.Body, service.ErrCodeIdempotencyKeyInProgress)
+	}
+
+	close(resume)
+	if rec := <-done; rec.Code != http.StatusOK {
+		t.Fatalf("first request: %d %s", rec.Code, rec.Body)
+	}
+	api.faults.beforeOrderWrite = nil
+
+	if rec := send(); rec.Code != http.StatusOK || rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry after the first request = %d replayed %q, want 200 replayed", rec.Code, rec.Header().Get("Idempotent-Replayed"))
+	}
+	if wallet := api.wallet(token); wallet.Available.String() != "900" {
+		t.Errorf("available = %s, want 900 after one debit", wallet.Available)
+	}
+}
+
+func TestIdempotentOrderRetriedAfterConflict(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	portID := api.openAccount(token)
+	send := func() *httptest.ResponseRecorder {
+		return api.do(http.MethodPost, "/sim/v1/port/buy", token, order(portID, testFundCode, "100", "10"), "Idempotency-Key", "order-1")
+	}
+
+	// Every attempt to debit the wallet loses the race
+	api.faults.walletConflicts = 5
+	if rec := send(); rec.Code != http.StatusConflict || errorCode(rec) != service.ErrCodeConcurrentUpdate {
+		t.Fatalf("order while the wallet is busy = %d %s, want 409 %s", rec.Code, rec.Body, service.ErrCodeConcurrentUpdate)
+	}
+
+	// The retry the error asks for places the order instead of replaying the conflict
+	rec := send()
+	if rec.Code != http.StatusOK || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("retry = %d replayed %q: %s, want the order placed", rec.Code, rec.Header().Get("Idempotent-Replayed"), rec.Body)
+	}
+	if wallet := api.wallet(token); wallet.Available.String() != "900" {
+		t.Errorf("available = %s, want 900 after one debit", wallet.Available)
+	}
+	if rec := send(); rec.Code != http.StatusOK || rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("second retry = %d replayed %q, want the order replayed", rec.Code, rec.Header().Get("Idempotent-Replayed"))
+	}
+}
+
+func TestIdempotentOrderBodyLimit(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	portID := api.openAccount(token)
+
+	body := struct {
+		dto.OrderRequest
+		Padding string `json:"padding"`
+	}{order(portID, testFundCode, "100", "10"), strings.Repeat("x", 64<<10)}
+	rec := api.do(http.MethodPost, "/sim/v1/port/buy", token, body, "Idempotency-Key", "order-1")
+	if rec.Code != http.StatusRequestEntityTooLarge || errorCode(rec) != service.ErrCodeRequestTooLarge {
+		t.Errorf("oversized order = %d %s, want 413 %s", rec.Code, rec.Body, service.ErrCodeRequestTooLarge)
+	}
+	if wallet := api.wallet(token); wallet.Available.String() != "1000" {
+		t.Errorf("available = %s, want the start balance", wallet.Available)
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"gitlab.com/investio/backend/sim-api/v1/openapi"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+// ginParam matches a path parameter in gin syntax, /funds/:code
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+// checkResponse fails the test when a /sim/v1 response does not match the spec,
+// so every test that calls the API also checks the contract
+func checkResponse(t *testing.T, req *http.Request, rec *httptest.ResponseRecorder) {
+	t.Helper()
+
+	spec := openapi.MustLoad()
+	route, pathParams, err := spec.FindRoute(req)
+	if err != nil {
+		if strings.HasPrefix(req.URL.Path, "/sim/v1/") && rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s is not in the spec: %v", req.Method, req.URL.Path, err)
+		}
+		return
+	}
+
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status:  rec.Code,
+		Header:  rec.Header(),
+		Body:    rec.Result().Body,
+		Options: &openapi3filter.Options{IncludeResponseStatus: true},
+	})
+	if err != nil {
+		t.Errorf("%s %s responded %d against the spec: %v", req.Method, req.URL.Path, rec.Code, err)
+	}
+}
+
+func TestSpecDescribesEveryRoute(t *testing.T) {
+	api := newTestAPI(t)
+	spec := openapi.MustLoad()
+
+	var routes []string
+	for _, r := range api.server.router.Routes() {
+		if strings.HasPrefix(r.Path, "/sim/v1/") {
+			routes = append(routes, r.Method+" "+ginParam.ReplaceAllString(r.Path, "{$1}"))
+		}
+	}
+
+	var operations []string
+	for path, item := range spec.Doc.Paths.Map() {
+		for method := range item.Operations() {
+			operations = append(operations, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(operations)
+	if strings.Join(routes, "\n") != strings.Join(operations, "\n") {
+		t.Errorf("routes and spec disagree\nroutes:\n  %s\nspec:\n  %s",
+			strings.Join(routes, "\n  "), strings.Join(operations, "\n  "))
+	}
+}
+
+func TestServesSpec(t *testing.T) {
+	api := newTestAPI(t)
+
+	rec := api.do(http.MethodGet, "/sim/v1/openapi.json", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var doc map[string]interface{}
+	decode(t, rec, &doc)
+	if doc["openapi"] != "3.0.3" {
+		t.Errorf("openapi = %v, want 3.0.3", doc["openapi"])
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	portID := api.openAccount(token)
+
+	order := func(edit func(map[string]interface{})) map[string]interface{} {
+		body := map[string]interface{}{
+			"port_id":   portID,
+			"fund_code": testFundCode,
+			"amount":    "100",
+			"unit":      "10",
+			"nav":       10,
+			"date":      "2021-06-30",
+		}
+		edit(body)
+		return body
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   interface{}
+		status int
+		code   service.ErrorCode
+	}{
+		{"valid order", http.MethodPost, "/sim/v1/port/buy", token, order(func(map[string]interface{}) {}), http.StatusOK, ""},
+		{"amount as number", http.MethodPost, "/sim/v1/port/buy", token, order(func(b map[string]interface{}) { b["amount"] = 100 }), http.StatusOK, ""},
+		{"amount not a decimal", http.MethodPost, "/sim/v1/port/buy", token, order(func(b map[string]interface{}) { b["amount"] = "lots" }), http.StatusUnprocessableEntity, "INVALID_REQUEST"},
+		{"port id not a number", http.MethodPost, "/sim/v1/port/sell", token, order(func(b map[string]interface{}) { b["port_id"] = "one" }), http.StatusUnprocessableEntity, "INVALID_REQUEST"},
+		{"missing fund code", http.MethodPost, "/sim/v1/port/buy", token, order(func(b map[string]interface{}) { delete(b, "fund_code") }), http.StatusUnprocessableEntity, "INVALID_REQUEST"},
+		{"missing body", http.MethodPost, "/sim/v1/port/buy", token, nil, http.StatusUnprocessableEntity, "INVALID_REQUEST"},
+		{"no token comes first", http.MethodPost, "/sim/v1/port/buy", "", map[string]interface{}{"amount": "lots"}, http.StatusUnauthorized, "TOKEN_MISSING"},
+		{"search funds", http.MethodGet, "/sim/v1/funds?q=SIM&limit=5", "", nil, http.StatusOK, ""},
+		{"get fund", http.MethodGet, "/sim/v1/funds/" + testFundCode, "", nil, http.StatusOK, ""},
+		{"unknown fund", http.MethodGet, "/sim/v1/funds/NOPE", "", nil, http.StatusNotFound, "FUND_NOT_FOUND"},
+		{"version", http.MethodGet, "/sim/v1/ver", "", nil, http.StatusOK, ""},
+		{"limit not a number", http.MethodGet, "/sim/v1/funds?limit=ten", "", nil, http.StatusUnprocessableEntity, "INVALID_REQUEST"},
+		{"limit below one", http.MethodGet, "/sim/v1/funds?limit=0", "", nil, http.StatusUnprocessableEntity, "INVALID_REQUEST"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := api.do(c.method, c.path, c.token, c.body)
+			if rec.Code != c.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, c.status, rec.Body)
+			}
+			if c.code != "" {
+				if code := errorCode(rec); code != c.code {
+					t.Errorf("code = %s, want %s", code, c.code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/investio/backend/sim-api/config"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+func TestRateLimit(t *testing.T) {
+	// Two orders at once, then one every 10 seconds
+	api := newTestAPI(t, func(cfg *config.Config) {
+		cfg.RateLimit.Order = config.LimitConfig{Rate: 0.1, Burst: 2}
+	})
+	token := api.token(7, time.Hour)
+	portID := api.openAccount(token)
+	buy := func(token string, portID uint) *httptest.ResponseRecorder {
+		return api.do(http.MethodPost, "/sim/v1/port/buy", token, order(portID, testFundCode, "10", "1"))
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := buy(token, portID); rec.Code != http.StatusOK {
+			t.Fatalf("order %d within the burst: %d %s", i+1, rec.Code, rec.Body)
+		}
+	}
+	rec := buy(token, portID)
+	if rec.Code != http.StatusTooManyRequests || errorCode(rec) != service.ErrCodeRateLimited {
+		t.Fatalf("order over the burst = %d %s, want 429 %s", rec.Code, rec.Body, service.ErrCodeRateLimited)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("Retry-After = %q, want the 10 seconds until the next token", got)
+	}
+
+	// Reads have their own bucket, and other users their own
+	if rec := api.do(http.MethodGet, "/sim/v1/wallet", token, nil); rec.Code != http.StatusOK {
+		t.Errorf("read of a limited user = %d, want 200", rec.Code)
+	}
+	other := api.token(8, time.Hour)
+	if rec := buy(other, api.openAccount(other)); rec.Code != http.StatusOK {
+		t.Errorf("order of another user = %d %s, want 200", rec.Code, rec.Body)
+	}
+
+	// Orders go through while the limiter is down
+	api.faults.limiterDown = true
+	if rec := buy(token, portID); rec.Code != http.StatusOK {
+		t.Errorf("order with the limiter down = %d %s, want 200", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/config"
+	"gitlab.com/investio/backend/sim-api/events"
+	"gitlab.com/investio/backend/sim-api/metrics"
+	"gitlab.com/investio/backend/sim-api/ratelimit"
+	"gitlab.com/investio/backend/sim-api/v1/controller"
+	"gitlab.com/investio/backend/sim-api/v1/middleware"
+	"gitlab.com/investio/backend/sim-api/v1/openapi"
+	"gitlab.com/investio/backend/sim-api/v1/schema"
+	v2controller "gitlab.com/investio/backend/sim-api/v2/controller"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// newRouter builds the controllers on svc and registers every route
+func newRouter(cfg config.Config, svc services, limiter ratelimit.Limiter, bus events.Bus) *gin.Engine {
+	var (
+		portController        = controller.NewPortController(svc.port, svc.order)
+		walletController      = controller.NewWalletController(svc.wallet)
+		transactionController = controller.NewTransactionController(svc.transaction)
+		fundController        = controller.NewFundController(svc.fund)
+		adminController       = controller.NewAdminController(svc.admin, svc.port, svc.wallet, svc.transaction)
+		healthController      = controller.NewHealthController(svc.health)
+		streamController      = controller.NewStreamController(bus, svc.wallet, svc.valuation)
+
+		spec              = openapi.MustLoad()
+		openAPIController = controller.NewOpenAPIController(spec)
+
+		v2WalletController = v2controller.NewWalletController(svc.wallet)
+		v2PortController   = v2controller.NewPortController(svc.port)
+		v2OrderController  = v2controller.NewOrderController(svc.order, svc.transaction)
+	)
+
+	r := gin.New()
+
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+	r.Use(middleware.RequestID())
+	r.Use(gin.Recovery())
+	r.Use(middleware.Metrics())
+	r.Use(middleware.RequestLogger())
+	r.Use(middleware.CORS(cfg.CORS))
+	r.Use(middleware.SecurityHeaders(cfg.Security))
+	r.Use(middleware.ErrorHandler())
+
+	r.GET("/healthz", healthController.Live)
+	r.GET("/readyz", healthController.Ready)
+
+	authenticate := middleware.Authenticate(svc.auth)
+	readLimit := middleware.RateLimit(limiter, middleware.RateLimitRead, ratelimit.Limit(cfg.RateLimit.Read))
+	orderLimit := middleware.RateLimit(limiter, middleware.RateLimitOrder, ratelimit.Limit(cfg.RateLimit.Order))
+	idempotent := middleware.Idempotency(svc.idempotency)
+
+	v1 := r.Group("/sim/v1")
+	{
+		// Validated after Authenticate, so a request without a token gets 401 whatever its body
+		validate := middleware.ValidateRequest(spec)
+
+		v1.GET("/openapi.json", openAPIController.GetSpec)
+		v1.GET("/funds", validate, fundController.SearchFunds)
+		v1.GET("/funds/:code", validate, fundController.GetFund)
+		v1.GET("/ver", healthController.GetVersion)
+
+		authorized := v1.Group("", authenticate, validate)
+
+		authorized.GET("/port", readLimit, portController.GetFundsInPort)
+		p := authorized.Group("/port")
+		{
+			p.POST("/buy", middleware.OrderMetrics(metrics.SideBuy), orderLimit, idempotent, portController.BuyFund)
+			p.POST("/sell", middleware.OrderMetrics(metrics.SideSell), orderLimit, idempotent, portController.SellFund)
+		}
+		authorized.GET("/wallet", readLimit, walletController.GetWallet)
+		authorized.GET("/orders", readLimit, transactionController.GetTransaction)
+		authorized.GET("/stream", readLimit, streamController.Stream)
+	}
+
+	// v2 shares the services of v1 and only differs in routes and bodies
+	v2 := r.Group("/sim/v2", authenticate)
+	{
+		v2.GET("/wallet", readLimit, v2WalletController.GetWallet)
+		v2.GET("/ports", readLimit, v2PortController.ListPorts)
+		v2.GET("/ports/:id", readLimit, v2PortController.GetPort)
+		v2.GET("/ports/:id/holdings", readLimit, v2PortController.ListHoldings)
+		v2.GET("/orders", readLimit, v2OrderController.ListOrders)
+		v2.POST("/orders", middleware.OrderMetrics(""), orderLimit, idempotent, v2OrderController.PlaceOrder)
+	}
+
+	admin := r.Group("/sim/admin", authenticate, middleware.RequireRole(schema.RoleAdmin))
+	{
+		u := admin.Group("/users/:uid")
+		{
+			u.GET("/wallet", adminController.GetUserWallet)
+			u.GET("/port", adminController.GetUserPort)
+			u.GET("/orders", adminController.GetUserOrders)
+			u.GET("/audit", adminController.GetUserAuditLogs)
+
+			w := u.Group("", middleware.RequireScope(schema.ScopeAdminWrite))
+			w.POST("/wallet/adjustments", adminController.AdjustBalance)
+			w.POST("/freeze", adminController.FreezeAccount)
+			w.POST("/unfreeze", adminController.UnfreezeAccount)
+		}
+	}
+
+	return r
+}
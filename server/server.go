@@ -0,0 +1,250 @@
+// Package server builds the simulator API on its stores and clients and runs it over HTTP
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/investio/backend/sim-api/config"
+	"gitlab.com/investio/backend/sim-api/events"
+	"gitlab.com/investio/backend/sim-api/metrics"
+	"gitlab.com/investio/backend/sim-api/ratelimit"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"google.golang.org/grpc"
+)
+
+// idempotencyPurgeInterval is how often Run removes expired idempotency keys
+const idempotencyPurgeInterval = time.Hour
+
+// Deps are the stores and clients the API is built on. Tests pass a local
+// database and a test key set.
+type Deps struct {
+	Repos       repository.Repositories
+	Transactor  repository.Transactor
+	Keys        service.KeySet
+	Health      service.HealthService
+	RateLimiter ratelimit.Limiter
+}
+
+// Server is the simulator API over HTTP, and over gRPC for other backend services.
+// It holds no global state, so several servers with different settings can run in one process.
+type Server struct {
+	cfg    config.Config
+	router *gin.Engine
+	grpc   *grpc.Server
+	bus    events.Bus
+
+	health      service.HealthService
+	fund        service.FundService
+	idempotency service.IdempotencyService
+	valuation   service.ValuationService
+}
+
+// services are built once per Server and shared by its controllers
+type services struct {
+	port        service.PortService
+	wallet      service.WalletService
+	transaction service.TransactionService
+	fund        service.FundService
+	order       service.OrderService
+	admin       service.AdminService
+	idempotency service.IdempotencyService
+	auth        service.AuthService
+	health      service.HealthService
+	valuation   service.ValuationService
+}
+
+// New builds the services on deps and registers every route and gRPC service
+func New(cfg config.Config, deps Deps) *Server {
+	repos := deps.Repos
+	bus := events.NewMemoryBus()
+	svc := services{
+		port:        service.NewPortService(repos.Ports, repos.PortFunds),
+		wallet:      service.NewWalletService(repos.Wallets, cfg.Wallet, bus),
+		transaction: service.NewTransctionService(repos.Transactions),
+		fund:        service.NewFundService(repos.Funds, bus),
+		admin:       service.NewAdminService(repos.AuditLogs, deps.Transactor, bus),
+		idempotency: service.NewIdempotencyService(repos.Idempotency, cfg.Idempotency),
+		auth:        service.NewAuthService(deps.Keys, cfg.Auth),
+		health:      deps.Health,
+	}
+	svc.order = service.NewOrderService(svc.port, svc.wallet, svc.transaction, svc.fund, bus)
+	svc.valuation = service.NewValuationService(svc.port, repos.Funds)
+
+	return &Server{
+		cfg:         cfg,
+		router:      newRouter(cfg, svc, deps.RateLimiter, bus),
+		grpc:        newGRPCServer(svc),
+		bus:         bus,
+		health:      deps.Health,
+		fund:        svc.fund,
+		idempotency: svc.idempotency,
+		valuation:   svc.valuation,
+	}
+}
+
+// ServeHTTP makes the Server an http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// LoadFundCatalogue refreshes the fund catalogue from the seed file, or from
+// the upstream fund API when no seed file is configured
+func (s *Server) LoadFundCatalogue(ctx context.Context) {
+	var (
+		count int
+		err   error
+	)
+	if s.cfg.Fund.SeedFile != "" {
+		count, err = s.fund.LoadFromFile(ctx, s.cfg.Fund.SeedFile)
+	} else if s.cfg.Fund.APIURL != "" {
+		count, err = s.fund.LoadFromAPI(ctx, s.cfg.Fund.APIURL)
+	} else {
+		log.Warn("Server: No fund catalogue source, using funds already in the database")
+		return
+	}
+
+	if err != nil {
+		log.Error("Server: Load fund catalogue failed ", err)
+		return
+	}
+	log.Infof("Server: Loaded %d funds into the catalogue", count)
+}
+
+// Run serves HTTP, and gRPC and metrics unless their port is 0, until ctx is done, then stops
+// accepting connections and lets in-flight requests, such as a buy half-way
+// through its steps, finish within the shutdown timeout. Event streams end
+// as soon as the shutdown starts.
+func (s *Server) Run(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.cfg.Server.Port),
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv.RegisterOnShutdown(s.bus.Close)
+	defer s.bus.Close()
+
+	var grpcListener net.Listener
+	if s.cfg.Server.GRPCPort != 0 {
+		var err error
+		if grpcListener, err = net.Listen("tcp", fmt.Sprintf(":%d", s.cfg.Server.GRPCPort)); err != nil {
+			return fmt.Errorf("grpc: %w", err)
+		}
+	}
+
+	// Metrics are served on their own port, kept off the public network, rather than next to the API
+	var metricsSrv *http.Server
+	if s.cfg.Server.MetricsPort != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		metricsSrv = &http.Server{
+			Addr:              fmt.Sprintf(":%d", s.cfg.Server.MetricsPort),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		defer metricsSrv.Close()
+	}
+
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go s.purgeIdempotencyKeys(bgCtx, idempotencyPurgeInterval)
+	go s.valuation.Watch(bgCtx, s.bus)
+	if interval := s.cfg.Fund.RefreshInterval; interval > 0 {
+		go s.refreshFundCatalogue(bgCtx, interval)
+	}
+
+	errCh := make(chan error, 3)
+	go func() {
+		log.Info("Server: Listening on ", srv.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
+	if grpcListener != nil {
+		go func() {
+			log.Info("Server: gRPC listening on ", grpcListener.Addr())
+			if err := s.grpc.Serve(grpcListener); err != nil {
+				errCh <- fmt.Errorf("grpc: %w", err)
+			}
+		}()
+	}
+	if metricsSrv != nil {
+		go func() {
+			log.Info("Server: Metrics listening on ", metricsSrv.Addr)
+			if err := metricsSrv.ListenAndServe(); err != http.ErrServerClosed {
+				errCh <- fmt.Errorf("metrics: %w", err)
+			}
+		}()
+	}
+
+	select {
+	case err := <-errCh:
+		s.grpc.Stop()
+		_ = srv.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := s.cfg.Server.ShutdownTimeout
+	log.Info("Server: Shutting down, draining requests for up to ", timeout)
+	s.health.Drain()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(grpcStopped)
+	}()
+
+	err := srv.Shutdown(shutdownCtx)
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		s.grpc.Stop()
+	}
+	if err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	return nil
+}
+
+// purgeIdempotencyKeys removes expired idempotency keys every interval until ctx is done
+func (s *Server) purgeIdempotencyKeys(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := s.idempotency.PurgeExpired(ctx)
+			if err != nil {
+				log.Error("Server: Purge idempotency keys failed ", err)
+			} else if count > 0 {
+				log.Debugf("Server: Purged %d expired idempotency keys", count)
+			}
+		}
+	}
+}
+
+// refreshFundCatalogue reloads the fund catalogue every interval until ctx is done
+func (s *Server) refreshFundCatalogue(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.LoadFundCatalogue(ctx)
+		}
+	}
+}
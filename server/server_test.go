@@ -0,0 +1,571 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/config"
+	"gitlab.com/investio/backend/sim-api/metrics"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+	"gitlab.com/investio/backend/sim-api/v1/schema"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestAuthentication(t *testing.T) {
+	api := newTestAPI(t)
+	other := newTestAPI(t)
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantCode   service.ErrorCode
+	}{
+		{"valid token", api.token(1, time.Hour), http.StatusOK, ""},
+		{"missing token", "", http.StatusUnauthorized, service.ErrCodeTokenMissing},
+		{"malformed token", "not-a-jwt", http.StatusUnauthorized, service.ErrCodeTokenMalformed},
+		{"expired token", api.token(1, -time.Minute), http.StatusUnauthorized, service.ErrCodeTokenExpired},
+		{"signed by another key", other.token(1, time.Hour), http.StatusUnauthorized, service.ErrCodeTokenInvalid},
+		{"without expiry", api.sign(schema.TokenClaims{UserID: 1, Claims: &jwt.Claims{}}), http.StatusUnauthorized, service.ErrCodeTokenMalformed},
+		{"refresh token", api.sign(schema.TokenClaims{
+			UserID:    1,
+			IsRefresh: true,
+			Claims:    &jwt.Claims{Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		}), http.StatusUnauthorized, service.ErrCodeRefreshToken},
+	}
+
+	for _, path := range []string{"/sim/v1/wallet", "/sim/v1/port", "/sim/v1/orders"} {
+		for _, tt := range tests {
+			t.Run(path+"/"+tt.name, func(t *testing.T) {
+				rec := api.do(http.MethodGet, path, tt.token, nil)
+				if rec.Code != tt.wantStatus || errorCode(rec) != tt.wantCode {
+					t.Errorf("got %d %q, want %d %q: %s", rec.Code, errorCode(rec), tt.wantStatus, tt.wantCode, rec.Body)
+				}
+			})
+		}
+	}
+}
+
+func TestTokenClaims(t *testing.T) {
+	const issuer = "https://auth.investio.test"
+	api := newTestAPI(t, func(cfg *config.Config) {
+		cfg.Auth.Issuer = issuer
+		cfg.Auth.Audience = []string{"sim-api"}
+		cfg.Auth.Leeway = time.Minute
+	})
+	now := time.Now()
+	at := func(d time.Duration) *jwt.NumericDate { return jwt.NewNumericDate(now.Add(d)) }
+
+	// valid claims, changed by each test
+	valid := func() *jwt.Claims {
+		return &jwt.Claims{
+			Issuer:    issuer,
+			Audience:  jwt.Audience{"sim-api"},
+			IssuedAt:  at(-time.Minute),
+			NotBefore: at(-time.Minute),
+			Expiry:    at(time.Hour),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		change   func(c *jwt.Claims)
+		wantCode service.ErrorCode
+	}{
+		{"valid", func(c *jwt.Claims) {}, ""},
+		{"wrong issuer", func(c *jwt.Claims) { c.Issuer = "https://evil.test" }, service.ErrCodeTokenIssuer},
+		{"no issuer", func(c *jwt.Claims) { c.Issuer = "" }, service.ErrCodeTokenIssuer},
+		{"wrong audience", func(c *jwt.Claims) { c.Audience = jwt.Audience{"admin-api"} }, service.ErrCodeTokenAudience},
+		{"one of several audiences", func(c *jwt.Claims) { c.Audience = jwt.Audience{"admin-api", "sim-api"} }, ""},
+		{"nbf in the future", func(c *jwt.Claims) { c.NotBefore = at(5 * time.Minute) }, service.ErrCodeTokenNotYetValid},
+		{"nbf within the leeway", func(c *jwt.Claims) { c.NotBefore = at(30 * time.Second) }, ""},
+		{"iat in the future", func(c *jwt.Claims) { c.IssuedAt = at(5 * time.Minute) }, service.ErrCodeTokenIssuedInFuture},
+		{"iat within the leeway", func(c *jwt.Claims) { c.IssuedAt = at(30 * time.Second) }, ""},
+		{"missing exp", func(c *jwt.Claims) { c.Expiry = nil }, service.ErrCodeTokenMalformed},
+		{"expired within the leeway", func(c *jwt.Claims) { c.Expiry = at(-30 * time.Second) }, ""},
+		{"expired beyond the leeway", func(c *jwt.Claims) { c.Expiry = at(-2 * time.Minute) }, service.ErrCodeTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.change(claims)
+			token := api.sign(schema.TokenClaims{UserID: 1, IsAuthorized: true, Claims: claims})
+
+			rec := api.do(http.MethodGet, "/sim/v1/wallet", token, nil)
+			wantStatus := http.StatusOK
+			if tt.wantCode != "" {
+				wantStatus = http.StatusUnauthorized
+			}
+			if rec.Code != wantStatus || errorCode(rec) != tt.wantCode {
+				t.Errorf("got %d %q, want %d %q: %s", rec.Code, errorCode(rec), wantStatus, tt.wantCode, rec.Body)
+			}
+		})
+	}
+}
+
+func TestOrders(t *testing.T) {
+	type orderFunc func(portID uint) (path string, body interface{})
+
+	buy := func(amount, unit string) orderFunc {
+		return func(portID uint) (string, interface{}) {
+			return "/sim/v1/port/buy", order(portID, testFundCode, amount, unit)
+		}
+	}
+	sell := func(amount, unit string) orderFunc {
+		return func(portID uint) (string, interface{}) {
+			return "/sim/v1/port/sell", order(portID, testFundCode, amount, unit)
+		}
+	}
+
+	tests := []struct {
+		name string
+		// setup orders, which must succeed, placed before the order under test
+		setup         []orderFunc
+		order         orderFunc
+		wantStatus    int
+		wantCode      service.ErrorCode
+		wantAvailable string
+		wantUnits     string
+	}{
+		{
+			name:       "buy",
+			order:      buy("100", "10"),
+			wantStatus: http.StatusOK, wantAvailable: "900", wantUnits: "10",
+		},
+		{
+			name:       "buy the whole balance",
+			order:      buy("1000", "100"),
+			wantStatus: http.StatusOK, wantAvailable: "0", wantUnits: "100",
+		},
+		{
+			name:       "buy with insufficient balance",
+			order:      buy("1000.01", "100"),
+			wantStatus: http.StatusBadRequest, wantCode: service.ErrCodeInsufficientBalance,
+			wantAvailable: "1000", wantUnits: "0",
+		},
+		{
+			name: "buy into another user's port",
+			order: func(portID uint) (string, interface{}) {
+				return "/sim/v1/port/buy", order(portID+1, testFundCode, "100", "10")
+			},
+			wantStatus: http.StatusBadRequest, wantCode: service.ErrCodePortMismatch,
+			wantAvailable: "1000", wantUnits: "0",
+		},
+		{
+			name:       "buy negative units",
+			order:      buy("100", "-10"),
+			wantStatus: http.StatusBadRequest, wantCode: service.ErrCodeInvalidUnit,
+			wantAvailable: "1000", wantUnits: "0",
+		},
+		{
+			name:       "buy a negative amount",
+			order:      buy("-100", "10"),
+			wantStatus: http.StatusBadRequest, wantCode: service.ErrCodeInvalidAmount,
+			wantAvailable: "1000", wantUnits: "0",
+		},
+		{
+			name: "buy an unknown fund",
+			order: func(portID uint) (string, interface{}) {
+				return "/sim/v1/port/buy", order(portID, "NO-SUCH", "100", "10")
+			},
+			wantStatus: http.StatusNotFound, wantCode: service.ErrCodeFundNotFound,
+			wantAvailable: "1000", wantUnits: "0",
+		},
+		{
+			name: "buy an inactive fund",
+			order: func(portID uint) (string, interface{}) {
+				return "/sim/v1/port/buy", order(portID, inactiveFundCode, "100", "10")
+			},
+			wantStatus: http.StatusBadRequest, wantCode: service.ErrCodeFundInactive,
+			wantAvailable: "1000", wantUnits: "0",
+		},
+		{
+			name:       "sell",
+			setup:      []orderFunc{buy("300", "30")},
+			order:      sell("100", "10"),
+			wantStatus: http.StatusOK, wantAvailable: "800", wantUnits: "20",
+		},
+		{
+			name:       "sell negative units",
+			setup:      []orderFunc{buy("300", "30")},
+			order:      sell("100", "-10"),
+			wantStatus: http.StatusBadRequest, wantCode: service.ErrCodeInvalidUnit,
+			wantAvailable: "700", wantUnits: "30",
+		},
+		{
+			name:       "sell more than the asset balance",
+			setup:      []orderFunc{buy("300", "30")},
+			order:      sell("301", "30"),
+			wantStatus: http.StatusBadRequest, wantCode: service.ErrCodeInsufficientAsset,
+			wantAvailable: "700", wantUnits: "30",
+		},
+		{
+			// The wallet is credited first, then reversed when the holding is short
+			name:       "sell more units than held",
+			setup:      []orderFunc{buy("300", "30")},
+			order:      sell("100", "31"),
+			wantStatus: http.StatusBadRequest, wantCode: service.ErrCodeInsufficientUnits,
+			wantAvailable: "700", wantUnits: "30",
+		},
+		{
+			name:  "sell from another user's port",
+			setup: []orderFunc{buy("300", "30")},
+			order: func(portID uint) (string, interface{}) {
+				return "/sim/v1/port/sell", order(portID+1, testFundCode, "100", "10")
+			},
+			wantStatus: http.StatusBadRequest, wantCode: service.ErrCodePortMismatch,
+			wantAvailable: "700", wantUnits: "30",
+		},
+		{
+			name:       "invalid JSON",
+			order:      func(portID uint) (string, interface{}) { return "/sim/v1/port/buy", "not an order" },
+			wantStatus: http.StatusUnprocessableEntity, wantCode: service.ErrCodeInvalidRequest,
+			wantAvailable: "1000", wantUnits: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			token := api.token(7, time.Hour)
+			portID := api.openAccount(token)
+
+			for i, setup := range tt.setup {
+				path, body := setup(portID)
+				if rec := api.do(http.MethodPost, path, token, body); rec.Code != http.StatusOK {
+					t.Fatalf("setup order %d: %d %s", i, rec.Code, rec.Body)
+				}
+			}
+
+			path, body := tt.order(portID)
+			rec := api.do(http.MethodPost, path, token, body)
+			if rec.Code != tt.wantStatus || errorCode(rec) != tt.wantCode {
+				t.Fatalf("got %d %q, want %d %q: %s", rec.Code, errorCode(rec), tt.wantStatus, tt.wantCode, rec.Body)
+			}
+
+			if got := api.wallet(token).Available; !got.Equal(decimal.RequireFromString(tt.wantAvailable)) {
+				t.Errorf("available balance = %s, want %s", got, tt.wantAvailable)
+			}
+			if got := api.units(token, testFundCode); !got.Equal(decimal.RequireFromString(tt.wantUnits)) {
+				t.Errorf("units held = %s, want %s", got, tt.wantUnits)
+			}
+		})
+	}
+}
+
+func TestOrderHistory(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	portID := api.openAccount(token)
+
+	for _, o := range []struct{ path, amount, unit string }{
+		{"/sim/v1/port/buy", "300", "30"},
+		{"/sim/v1/port/sell", "100", "10"},
+	} {
+		if rec := api.do(http.MethodPost, o.path, token, order(portID, testFundCode, o.amount, o.unit)); rec.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", o.path, rec.Code, rec.Body)
+		}
+	}
+
+	rec := api.do(http.MethodGet, "/sim/v1/orders", token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get orders: %d %s", rec.Code, rec.Body)
+	}
+	var orders []model.Transaction
+	decode(t, rec, &orders)
+	if len(orders) != 2 {
+		t.Fatalf("got %d orders, want 2: %s", len(orders), rec.Body)
+	}
+	types := map[uint8]bool{}
+	for _, o := range orders {
+		types[uint8(o.Type)] = true
+		if o.PortID != portID || o.FundID != testFundID || o.FundCode != testFundCode {
+			t.Errorf("order %+v is not for port %d and fund %s", o, portID, testFundCode)
+		}
+	}
+	if !types[1] || !types[2] {
+		t.Errorf("want one buy (1) and one sell (2), got %s", rec.Body)
+	}
+
+	// Orders are private to their user
+	rec = api.do(http.MethodGet, "/sim/v1/orders", api.token(8, time.Hour), nil)
+	decode(t, rec, &orders)
+	if len(orders) != 0 {
+		t.Errorf("another user sees %d orders", len(orders))
+	}
+
+	wallet := api.wallet(token)
+	if !wallet.Available.Equal(decimal.NewFromInt(800)) || !wallet.InAsset.Equal(decimal.NewFromInt(200)) {
+		t.Errorf("wallet = %s available, %s in assets, want 800 and 200", wallet.Available, wallet.InAsset)
+	}
+}
+
+func TestReversals(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		// holding of 10 units, bought for 100 before the faults are set
+		holding       bool
+		faults        faults
+		wantCode      service.ErrorCode
+		wantFailedRev string // reversal step counted as failed, if any
+		wantAvailable string
+		wantUnits     string
+	}{
+		{
+			name:     "buy: order write fails, wallet and holding are reversed",
+			path:     "/sim/v1/port/buy",
+			faults:   faults{orderWrite: true},
+			wantCode: service.ErrCodeDatabase, wantAvailable: "1000", wantUnits: "0",
+		},
+		{
+			name: "buy: holding update fails, wallet is reversed",
+			path: "/sim/v1/port/buy", holding: true,
+			faults:   faults{holdingSaveFrom: 1},
+			wantCode: service.ErrCodeDatabase, wantAvailable: "900", wantUnits: "10",
+		},
+		{
+			name: "buy: holding update fails, wallet reversal fails",
+			path: "/sim/v1/port/buy", holding: true,
+			faults:   faults{holdingSaveFrom: 1, walletSaveFrom: 2},
+			wantCode: service.ErrCodeReversalFailed, wantFailedRev: metrics.StepWallet,
+			wantAvailable: "800", wantUnits: "10",
+		},
+		{
+			name:     "buy: order write fails, wallet reversal fails",
+			path:     "/sim/v1/port/buy",
+			faults:   faults{orderWrite: true, walletSaveFrom: 2},
+			wantCode: service.ErrCodeReversalFailed, wantFailedRev: metrics.StepWallet,
+			wantAvailable: "900", wantUnits: "10",
+		},
+		{
+			name: "buy: order write fails, holding reversal fails",
+			path: "/sim/v1/port/buy", holding: true,
+			faults:   faults{orderWrite: true, holdingSaveFrom: 2},
+			wantCode: service.ErrCodeReversalFailed, wantFailedRev: metrics.StepPort,
+			wantAvailable: "900", wantUnits: "20",
+		},
+		{
+			name: "sell: order write fails, wallet and holding are reversed",
+			path: "/sim/v1/port/sell", holding: true,
+			faults:   faults{orderWrite: true},
+			wantCode: service.ErrCodeDatabase, wantAvailable: "900", wantUnits: "10",
+		},
+		{
+			name: "sell: holding update fails, wallet is reversed",
+			path: "/sim/v1/port/sell", holding: true,
+			faults:   faults{holdingSaveFrom: 1},
+			wantCode: service.ErrCodeDatabase, wantAvailable: "900", wantUnits: "10",
+		},
+		{
+			name: "sell: holding update fails, wallet reversal fails",
+			path: "/sim/v1/port/sell", holding: true,
+			faults:   faults{holdingSaveFrom: 1, walletSaveFrom: 2},
+			wantCode: service.ErrCodeReversalFailed, wantFailedRev: metrics.StepWallet,
+			wantAvailable: "1000", wantUnits: "10",
+		},
+		{
+			name: "sell: order write fails, wallet reversal fails",
+			path: "/sim/v1/port/sell", holding: true,
+			faults:   faults{orderWrite: true, walletSaveFrom: 2},
+			wantCode: service.ErrCodeReversalFailed, wantFailedRev: metrics.StepWallet,
+			wantAvailable: "1000", wantUnits: "0",
+		},
+		{
+			name: "sell: order write fails, holding reversal fails",
+			path: "/sim/v1/port/sell", holding: true,
+			faults:   faults{orderWrite: true, holdingSaveFrom: 2},
+			wantCode: service.ErrCodeReversalFailed, wantFailedRev: metrics.StepPort,
+			wantAvailable: "900", wantUnits: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			token := api.token(7, time.Hour)
+			portID := api.openAccount(token)
+
+			if tt.holding {
+				if rec := api.do(http.MethodPost, "/sim/v1/port/buy", token, order(portID, testFundCode, "100", "10")); rec.Code != http.StatusOK {
+					t.Fatalf("setup buy: %d %s", rec.Code, rec.Body)
+				}
+			}
+
+			side := metrics.SideBuy
+			if tt.path == "/sim/v1/port/sell" {
+				side = metrics.SideSell
+			}
+			var failedBefore float64
+			if tt.wantFailedRev != "" {
+				failedBefore = testutil.ToFloat64(metrics.ReversalFailures.WithLabelValues(side, tt.wantFailedRev))
+			}
+
+			*api.faults = tt.faults
+			rec := api.do(http.MethodPost, tt.path, token, order(portID, testFundCode, "100", "10"))
+			*api.faults = faults{}
+
+			if rec.Code != http.StatusBadGateway || errorCode(rec) != tt.wantCode {
+				t.Fatalf("got %d %q, want 502 %q: %s", rec.Code, errorCode(rec), tt.wantCode, rec.Body)
+			}
+			if tt.wantFailedRev != "" {
+				failed := testutil.ToFloat64(metrics.ReversalFailures.WithLabelValues(side, tt.wantFailedRev)) - failedBefore
+				if failed != 1 {
+					t.Errorf("counted %v failed %s reversals, want 1", failed, tt.wantFailedRev)
+				}
+			}
+
+			if got := api.wallet(token).Available; !got.Equal(decimal.RequireFromString(tt.wantAvailable)) {
+				t.Errorf("available balance = %s, want %s", got, tt.wantAvailable)
+			}
+			if got := api.units(token, testFundCode); !got.Equal(decimal.RequireFromString(tt.wantUnits)) {
+				t.Errorf("units held = %s, want %s", got, tt.wantUnits)
+			}
+		})
+	}
+}
+
+func TestServersAreIndependent(t *testing.T) {
+	small := newTestAPI(t)
+	large := newTestAPI(t, func(cfg *config.Config) {
+		cfg.Wallet.StartBalance = decimal.NewFromInt(5000)
+	})
+
+	for _, tt := range []struct {
+		name string
+		api  *testAPI
+		want string
+	}{
+		{"default start balance", small, "1000"},
+		{"configured start balance", large, "5000"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			token := tt.api.token(7, time.Hour)
+			tt.api.openAccount(token)
+			if got := tt.api.wallet(token).Available; !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("start balance = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// freePort returns a local TCP port that was free a moment ago
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestRunStopsWhenContextIsDone(t *testing.T) {
+	port, grpcPort, metricsPort := freePort(t), freePort(t), freePort(t)
+
+	api := newTestAPI(t, func(cfg *config.Config) {
+		cfg.Server.Port = uint64(port)
+		cfg.Server.GRPCPort = uint64(grpcPort)
+		cfg.Server.MetricsPort = uint64(metricsPort)
+		cfg.Server.ShutdownTimeout = 5 * time.Second
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- api.server.Run(ctx) }()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/healthz", port)
+	var (
+		resp *http.Response
+		err  error
+	)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err = http.Get(url); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("server did not start: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /healthz = %d, want 200", resp.StatusCode)
+	}
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", grpcPort), time.Second)
+	if err != nil {
+		t.Fatalf("gRPC server did not start: %v", err)
+	}
+	conn.Close()
+
+	// Metrics are only served on their own port
+	for _, tc := range []struct {
+		port int
+		want int
+	}{{metricsPort, http.StatusOK}, {port, http.StatusNotFound}} {
+		resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", tc.port))
+		if err != nil {
+			t.Fatalf("GET /metrics on port %d: %v", tc.port, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("GET /metrics on port %d = %d, want %d", tc.port, resp.StatusCode, tc.want)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after the context was done")
+	}
+
+	if rec := api.do(http.MethodGet, "/readyz", "", nil); rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"shutting down"`) {
+		t.Errorf("GET /readyz after shutdown = %d %s, want 503 while draining", rec.Code, rec.Body)
+	}
+}
+
+func TestReadyHidesDatabaseErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "sim.db")
+	sqlDB, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	store := repository.NewMemoryStore()
+	srv := New(config.Default(), Deps{
+		Repos:      store.Repositories(),
+		Transactor: store,
+		Health:     service.NewHealthService(sqlDB, nil),
+	})
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /readyz without a database = %d, want 503", rec.Code)
+	}
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	decode(t, rec, &body)
+	if body.Reason != "database unavailable" {
+		t.Errorf("reason = %q, want the fixed %q and not the driver error", body.Reason, "database unavailable")
+	}
+}
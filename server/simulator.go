@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/metrics"
+	simv1 "gitlab.com/investio/backend/sim-api/proto/sim/v1"
+	v1dto "gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"gitlab.com/investio/backend/sim-api/v2/dto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// simulatorServer is the gRPC API for other backend services. It reads and
+// writes through the same services as the HTTP API and answers with the v2 shapes.
+type simulatorServer struct {
+	simv1.UnimplementedSimulatorServer
+	svc services
+}
+
+func (s *simulatorServer) GetPortfolio(ctx context.Context, req *simv1.GetPortfolioRequest) (*simv1.Portfolio, error) {
+	var (
+		port  model.Port
+		funds []model.PortFund
+	)
+
+	userID, err := requestUserID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := s.svc.port.GetPort(ctx, &port, userID); err != nil {
+		return nil, grpcError(err)
+	}
+	if err := s.svc.port.GetFunds(ctx, &funds, port.ID); err != nil {
+		return nil, grpcError(service.AsError(err, service.ErrCodeDatabase))
+	}
+
+	p := dto.NewPort(port)
+	resp := &simv1.Portfolio{
+		PortId:     uint64(p.ID),
+		Name:       p.Name,
+		RealizedPl: p.RealizedPL,
+		TotalCost:  p.TotalCost,
+	}
+	for _, h := range dto.NewHoldings(funds) {
+		resp.Holdings = append(resp.Holdings, &simv1.Holding{
+			FundId:     h.FundID,
+			FundCode:   h.FundCode,
+			CategoryId: uint32(h.CategoryID),
+			Cost:       h.Cost,
+			Units:      h.Units,
+			RealizedPl: h.RealizedPL,
+		})
+	}
+	return resp, nil
+}
+
+func (s *simulatorServer) GetWallet(ctx context.Context, req *simv1.GetWalletRequest) (*simv1.Wallet, error) {
+	var wallet model.Wallet
+
+	userID, err := requestUserID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := s.svc.wallet.GetWallet(ctx, &wallet, userID); err != nil {
+		return nil, grpcError(err)
+	}
+
+	w := dto.NewWallet(wallet)
+	return &simv1.Wallet{
+		AvailableBalance: w.AvailableBalance,
+		InOrderBalance:   w.InOrderBalance,
+		InAssetBalance:   w.InAssetBalance,
+		TotalSpent:       w.TotalSpent,
+		Frozen:           w.Frozen,
+	}, nil
+}
+
+func (s *simulatorServer) ListOrders(ctx context.Context, req *simv1.ListOrdersRequest) (*simv1.ListOrdersResponse, error) {
+	var trans []model.Transaction
+
+	userID, err := requestUserID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := s.svc.transaction.Get(ctx, &trans, userID); err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &simv1.ListOrdersResponse{}
+	for _, o := range dto.NewOrders(trans) {
+		resp.Orders = append(resp.Orders, toOrder(o))
+	}
+	return resp, nil
+}
+
+func (s *simulatorServer) PlaceOrder(ctx context.Context, req *simv1.PlaceOrderRequest) (resp *simv1.Order, err error) {
+	side := metrics.SideUnknown
+	defer func() {
+		code := metrics.CodeOK
+		if err != nil {
+			code = string(service.CodeOf(err))
+		}
+		metrics.Orders.WithLabelValues(side, code).Inc()
+		if err != nil {
+			err = grpcError(err)
+		}
+	}()
+
+	userID, err := requestUserID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	order, err := orderRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var tran model.Transaction
+	switch req.GetSide() {
+	case simv1.OrderSide_ORDER_SIDE_BUY:
+		side = metrics.SideBuy
+		tran, err = s.svc.order.Buy(ctx, userID, order)
+	case simv1.OrderSide_ORDER_SIDE_SELL:
+		side = metrics.SideSell
+		tran, err = s.svc.order.Sell(ctx, userID, order)
+	default:
+		err = service.NewError(service.ErrCodeInvalidRequest, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toOrder(dto.NewOrder(tran)), nil
+}
+
+// requestUserID checks the user ID of a request and adds it to the log fields
+func requestUserID(ctx context.Context, id uint64) (uint, error) {
+	if id == 0 || id > math.MaxUint32 {
+		return 0, service.NewError(service.ErrCodeInvalidRequest, nil)
+	}
+	logging.SetUserID(ctx, uint(id))
+	return uint(id), nil
+}
+
+// orderRequest parses the decimals and date of a PlaceOrderRequest. The NAV and its date may be empty.
+func orderRequest(req *simv1.PlaceOrderRequest) (order v1dto.OrderRequest, err error) {
+	invalid := func(err error) (v1dto.OrderRequest, error) {
+		return v1dto.OrderRequest{}, service.NewError(service.ErrCodeInvalidRequest, err)
+	}
+
+	if req.GetPortId() > math.MaxUint32 {
+		return invalid(nil)
+	}
+	order = v1dto.OrderRequest{
+		PortID:   uint(req.GetPortId()),
+		FundID:   req.GetFundId(),
+		FundCode: req.GetFundCode(),
+	}
+	if order.Amount, err = decimal.NewFromString(req.GetAmount()); err != nil {
+		return invalid(err)
+	}
+	if order.Unit, err = decimal.NewFromString(req.GetUnits()); err != nil {
+		return invalid(err)
+	}
+	if req.GetNav() != "" {
+		if order.NAV, err = decimal.NewFromString(req.GetNav()); err != nil {
+			return invalid(err)
+		}
+	}
+	if req.GetNavDate() != "" {
+		date, err := time.Parse("2006-01-02", req.GetNavDate())
+		if err != nil {
+			return invalid(err)
+		}
+		order.DataDate = model.Date(date)
+	}
+	return order, nil
+}
+
+func toOrder(o dto.Order) *simv1.Order {
+	side := simv1.OrderSide_ORDER_SIDE_BUY
+	if o.Side == dto.OrderSell {
+		side = simv1.OrderSide_ORDER_SIDE_SELL
+	}
+	order := &simv1.Order{
+		Id:         uint64(o.ID),
+		Side:       side,
+		PortId:     uint64(o.PortID),
+		FundId:     o.FundID,
+		FundCode:   o.FundCode,
+		CategoryId: uint32(o.CategoryID),
+		Nav:        o.NAV,
+		Amount:     o.Amount,
+		Units:      o.Units,
+		CreatedAt:  timestamppb.New(o.CreatedAt),
+	}
+	if o.NAVDate != nil {
+		order.NavDate = o.NAVDate.Format("2006-01-02")
+	}
+	return order
+}
@@ -0,0 +1,318 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/metrics"
+	"gitlab.com/investio/backend/sim-api/v1/openapi"
+)
+
+// maxStreams is how many streams one user can open
+const maxStreams = 5
+
+// sseEvent is one event read from /sim/v1/stream
+type sseEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// schemaByEvent names the spec schema of the data of each event type
+var schemaByEvent = map[string]string{
+	"wallet":    "Wallet",
+	"valuation": "Valuation",
+	"order":     "OrderEvent",
+	"nav":       "FundNAV",
+}
+
+// eventStream is an open /sim/v1/stream
+type eventStream struct {
+	t      *testing.T
+	events <-chan sseEvent
+}
+
+// openStream opens the stream of the user on ts and reads its events until the response ends
+func openStream(t *testing.T, ts *httptest.Server, token string) (*http.Response, *eventStream) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/sim/v1/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	ch := make(chan sseEvent, 64)
+	go func() {
+		defer close(ch)
+		var e sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				if e.Type != "" {
+					ch <- e
+				}
+				e = sseEvent{}
+				continue
+			}
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "id":
+				e.ID = value
+			case "event":
+				e.Type = value
+			case "data":
+				e.Data = value
+			}
+		}
+	}()
+	return resp, &eventStream{t: t, events: ch}
+}
+
+// next skips to the next event of the type, checks its data against the spec and decodes it into v
+func (s *eventStream) next(eventType string, v interface{}) sseEvent {
+	s.t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-s.events:
+			if !ok {
+				s.t.Fatalf("stream ended waiting for a %s event", eventType)
+			}
+			if e.Type != eventType {
+				continue
+			}
+			checkEventData(s.t, e)
+			if err := json.Unmarshal([]byte(e.Data), v); err != nil {
+				s.t.Fatalf("decode %s event %s: %v", e.Type, e.Data, err)
+			}
+			return e
+		case <-timeout:
+			s.t.Fatalf("no %s event within 5s", eventType)
+		}
+	}
+}
+
+func checkEventData(t *testing.T, e sseEvent) {
+	t.Helper()
+
+	schema := openapi.MustLoad().Doc.Components.Schemas[schemaByEvent[e.Type]].Value
+	if e.Type == "nav" {
+		schema = openapi3.NewArraySchema().WithItems(schema)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(e.Data), &value); err != nil {
+		t.Fatalf("%s event is not JSON: %s", e.Type, e.Data)
+	}
+	if err := schema.VisitJSON(value); err != nil {
+		t.Errorf("%s event %s against the spec: %v", e.Type, e.Data, err)
+	}
+}
+
+type valuationEvent struct {
+	MarketValue  decimal.Decimal `json:"market_value"`
+	PlUnrealized decimal.Decimal `json:"pl_unrealized"`
+	Funds        []struct {
+		FundCode    string              `json:"code"`
+		NAV         decimal.NullDecimal `json:"nav"`
+		MarketValue decimal.Decimal     `json:"market_value"`
+	} `json:"funds"`
+}
+
+func TestStream(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	portID := api.openAccount(token)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go api.server.valuation.Watch(ctx, api.server.bus)
+	ts := httptest.NewServer(api.server)
+	t.Cleanup(ts.Close)
+
+	resp, stream := openStream(t, ts, token)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /sim/v1/stream = %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var wallet struct {
+		Available decimal.Decimal `json:"avalible_bal"`
+	}
+	var valuation valuationEvent
+	if e := stream.next("wallet", &wallet); e.ID != "" || !wallet.Available.Equal(testStartBalance) {
+		t.Errorf("opening wallet = %s with id %q, want %s without an id", wallet.Available, e.ID, testStartBalance)
+	}
+	if stream.next("valuation", &valuation); len(valuation.Funds) != 0 {
+		t.Errorf("opening valuation holds %d funds, want none", len(valuation.Funds))
+	}
+
+	// Another user's order is not streamed
+	other := api.token(8, time.Hour)
+	if rec := api.do(http.MethodPost, "/sim/v1/port/buy", other, order(api.openAccount(other), testFundCode, "50", "5")); rec.Code != http.StatusOK {
+		t.Fatalf("buy of user 8: %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := api.do(http.MethodPost, "/sim/v1/port/buy", token, order(portID, testFundCode, "100", "10")); rec.Code != http.StatusOK {
+		t.Fatalf("buy: %d %s", rec.Code, rec.Body)
+	}
+	var placed struct {
+		Status string `json:"status"`
+		Order  struct {
+			PortID uint            `json:"port_id"`
+			Amount decimal.Decimal `json:"amount"`
+		} `json:"order"`
+	}
+	stream.next("wallet", &wallet)
+	if e := stream.next("order", &placed); e.ID == "" || placed.Status != "completed" || placed.Order.PortID != portID {
+		t.Errorf("order event %+v with id %q, want a completed order on port %d", placed, e.ID, portID)
+	}
+	if want := testStartBalance.Sub(decimal.NewFromInt(100)); !wallet.Available.Equal(want) {
+		t.Errorf("streamed balance = %s, want %s", wallet.Available, want)
+	}
+
+	// Without a NAV the holding is valued at cost
+	stream.next("valuation", &valuation)
+	if len(valuation.Funds) != 1 || valuation.Funds[0].NAV.Valid || !valuation.MarketValue.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("valuation after the buy = %+v, want one fund at its cost of 100", valuation)
+	}
+
+	// A new NAV revalues the holding
+	seed := filepath.Join(t.TempDir(), "funds.json")
+	err := os.WriteFile(seed, []byte(`[{"fund_id": "`+testFundID+`", "code": "`+testFundCode+`", "bcat_id": 1, "nav": "12.5"}]`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.server.fund.LoadFromFile(ctx, seed); err != nil {
+		t.Fatal(err)
+	}
+	var navs []struct {
+		FundCode string          `json:"code"`
+		NAV      decimal.Decimal `json:"nav"`
+	}
+	if stream.next("nav", &navs); len(navs) != 1 || navs[0].FundCode != testFundCode || !navs[0].NAV.Equal(decimal.RequireFromString("12.5")) {
+		t.Errorf("nav event = %+v, want %s at 12.5", navs, testFundCode)
+	}
+	stream.next("valuation", &valuation)
+	if !valuation.MarketValue.Equal(decimal.NewFromInt(125)) || !valuation.PlUnrealized.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("valuation at NAV 12.5 = %s with P/L %s, want 125 and 25", valuation.MarketValue, valuation.PlUnrealized)
+	}
+
+	// An order that fails after validation is streamed with its error code
+	if rec := api.do(http.MethodPost, "/sim/v1/port/buy", token, order(portID, testFundCode, "5000", "400")); rec.Code == http.StatusOK {
+		t.Fatal("buy over the balance succeeded")
+	}
+	var failed struct {
+		Status string `json:"status"`
+		Code   string `json:"code"`
+	}
+	if stream.next("order", &failed); failed.Status != "failed" || failed.Code != "INSUFFICIENT_BALANCE" {
+		t.Errorf("failed order event = %+v, want failed with INSUFFICIENT_BALANCE", failed)
+	}
+
+	// Shutting down ends the stream
+	api.server.bus.Close()
+	timeout := time.After(5 * time.Second)
+	for open := true; open; {
+		select {
+		case _, open = <-stream.events:
+		case <-timeout:
+			t.Fatal("stream still open after the bus closed")
+		}
+	}
+}
+
+func TestStreamLimits(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+
+	if rec := api.do(http.MethodGet, "/sim/v1/stream", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("stream without a token = %d, want 401", rec.Code)
+	}
+
+	ts := httptest.NewServer(api.server)
+	t.Cleanup(ts.Close)
+
+	for i := 0; i < maxStreams; i++ {
+		if resp, _ := openStream(t, ts, token); resp.StatusCode != http.StatusOK {
+			t.Fatalf("stream %d = %d", i+1, resp.StatusCode)
+		}
+	}
+	resp, _ := openStream(t, ts, token)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusTooManyRequests || !strings.Contains(string(body), "RATE_LIMITED") {
+		t.Errorf("stream over the limit = %d %s, want 429 RATE_LIMITED", resp.StatusCode, body)
+	}
+
+	// Other users are not affected
+	if resp, _ := openStream(t, ts, api.token(8, time.Hour)); resp.StatusCode != http.StatusOK {
+		t.Errorf("stream of another user = %d, want 200", resp.StatusCode)
+	}
+}
+
+// sampleCount returns how many observations the histogram holds
+func sampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	t.Helper()
+
+	var m prommodel.Metric
+	if err := observer.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestStreamMetrics(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	api.openAccount(token)
+	ts := httptest.NewServer(api.server)
+	t.Cleanup(ts.Close)
+
+	requests := metrics.HTTPRequestDuration.WithLabelValues(http.MethodGet, "/sim/v1/stream", "200")
+	streams := metrics.StreamDuration.WithLabelValues("/sim/v1/stream")
+	requestsBefore, streamsBefore := sampleCount(t, requests), sampleCount(t, streams)
+
+	resp, stream := openStream(t, ts, token)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /sim/v1/stream = %d", resp.StatusCode)
+	}
+	var wallet struct{}
+	stream.next("wallet", &wallet)
+	resp.Body.Close()
+
+	for deadline := time.Now().Add(5 * time.Second); sampleCount(t, streams) == streamsBefore; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("closed stream not recorded in the stream duration")
+		}
+	}
+	if got := sampleCount(t, requests) - requestsBefore; got != 0 {
+		t.Errorf("stream recorded %d times in the request duration, want none", got)
+	}
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gitlab.com/investio/backend/sim-api/metrics"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+func v2Order(portID uint, side, amount, units string) map[string]interface{} {
+	return map[string]interface{}{
+		"port_id":   portID,
+		"fund_code": testFundCode,
+		"side":      side,
+		"amount":    amount,
+		"units":     units,
+		"nav":       "10",
+		"nav_date":  "2026-10-16",
+	}
+}
+
+// v2OpenAccount opens the wallet and port of the user through v2 and returns the port ID
+func (a *testAPI) v2OpenAccount(token string) uint {
+	a.t.Helper()
+
+	if rec := a.do(http.MethodGet, "/sim/v2/wallet", token, nil); rec.Code != http.StatusOK {
+		a.t.Fatalf("open wallet: %d %s", rec.Code, rec.Body)
+	}
+
+	rec := a.do(http.MethodGet, "/sim/v2/ports", token, nil)
+	if rec.Code != http.StatusOK {
+		a.t.Fatalf("list ports: %d %s", rec.Code, rec.Body)
+	}
+	var ports []struct {
+		ID uint `json:"id"`
+	}
+	decode(a.t, rec, &ports)
+	if len(ports) != 1 {
+		a.t.Fatalf("got %d ports, want 1: %s", len(ports), rec.Body)
+	}
+	return ports[0].ID
+}
+
+func TestV2Account(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+
+	rec := api.do(http.MethodGet, "/sim/v2/wallet", token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get wallet: %d %s", rec.Code, rec.Body)
+	}
+	var wallet map[string]interface{}
+	decode(t, rec, &wallet)
+	want := map[string]interface{}{
+		"available_balance": "1000.00",
+		"in_order_balance":  "0.00",
+		"in_asset_balance":  "0.00",
+		"total_spent":       "0.00",
+		"frozen":            false,
+	}
+	if !reflect.DeepEqual(wallet, want) {
+		t.Errorf("wallet = %v, want %v", wallet, want)
+	}
+
+	portID := api.v2OpenAccount(token)
+	if again := api.v2OpenAccount(token); again != portID {
+		t.Errorf("second visit opened port %d, want %d", again, portID)
+	}
+
+	rec = api.do(http.MethodGet, fmt.Sprintf("/sim/v2/ports/%d", portID), token, nil)
+	var port map[string]interface{}
+	decode(t, rec, &port)
+	if port["id"] != float64(portID) || port["realized_pl"] != "0.00" || port["total_cost"] != "0.00" {
+		t.Errorf("port = %v", port)
+	}
+
+	other := api.token(8, time.Hour)
+	api.v2OpenAccount(other)
+	for _, c := range []struct {
+		path   string
+		status int
+		code   service.ErrorCode
+	}{
+		{fmt.Sprintf("/sim/v2/ports/%d", portID), http.StatusNotFound, service.ErrCodePortNotFound},
+		{fmt.Sprintf("/sim/v2/ports/%d/holdings", portID), http.StatusNotFound, service.ErrCodePortNotFound},
+		{"/sim/v2/ports/first", http.StatusUnprocessableEntity, service.ErrCodeInvalidRequest},
+	} {
+		rec := api.do(http.MethodGet, c.path, other, nil)
+		if rec.Code != c.status || errorCode(rec) != c.code {
+			t.Errorf("%s by another user: %d %s, want %d %s", c.path, rec.Code, rec.Body, c.status, c.code)
+		}
+	}
+
+	if rec := api.do(http.MethodGet, "/sim/v2/wallet", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wallet without token: %d, want 401", rec.Code)
+	}
+}
+
+func TestV2Orders(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	portID := api.v2OpenAccount(token)
+
+	rec := api.do(http.MethodPost, "/sim/v2/orders", token, v2Order(portID, "buy", "300", "30"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("buy: %d %s", rec.Code, rec.Body)
+	}
+	var order map[string]interface{}
+	decode(t, rec, &order)
+	if _, err := time.Parse(time.RFC3339, fmt.Sprint(order["created_at"])); err != nil {
+		t.Errorf("created_at is not RFC 3339: %v", err)
+	}
+	delete(order, "created_at")
+	want := map[string]interface{}{
+		"id":          order["id"],
+		"side":        "buy",
+		"port_id":     float64(portID),
+		"fund_id":     testFundID,
+		"fund_code":   testFundCode,
+		"category_id": float64(1),
+		"nav":         "10.0000",
+		"nav_date":    "2026-10-16",
+		"amount":      "300.00",
+		"units":       "30.00000000",
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	if rec := api.do(http.MethodPost, "/sim/v2/orders", token, v2Order(portID, "sell", "100", "10")); rec.Code != http.StatusCreated {
+		t.Fatalf("sell: %d %s", rec.Code, rec.Body)
+	}
+
+	// Orders are counted under the side in the body
+	soldTooMuch := metrics.Orders.WithLabelValues(metrics.SideSell, string(service.ErrCodeInsufficientUnits))
+	before := testutil.ToFloat64(soldTooMuch)
+
+	bad := v2Order(portID, "buy", "10", "1")
+	bad["nav_date"] = "16/10/2026"
+	for _, c := range []struct {
+		name   string
+		body   map[string]interface{}
+		status int
+		code   service.ErrorCode
+	}{
+		{"unknown side", v2Order(portID, "hold", "10", "1"), http.StatusUnprocessableEntity, service.ErrCodeInvalidRequest},
+		{"date not ISO", bad, http.StatusUnprocessableEntity, service.ErrCodeInvalidRequest},
+		{"more than the balance", v2Order(portID, "buy", "5000", "500"), http.StatusBadRequest, service.ErrCodeInsufficientBalance},
+		{"more than held", v2Order(portID, "sell", "100", "50"), http.StatusBadRequest, service.ErrCodeInsufficientUnits},
+		{"another port", v2Order(portID+1, "buy", "10", "1"), http.StatusBadRequest, service.ErrCodePortMismatch},
+	} {
+		rec := api.do(http.MethodPost, "/sim/v2/orders", token, c.body)
+		if rec.Code != c.status || errorCode(rec) != c.code {
+			t.Errorf("%s: %d %s, want %d %s", c.name, rec.Code, rec.Body, c.status, c.code)
+		}
+	}
+
+	if got := testutil.ToFloat64(soldTooMuch) - before; got != 1 {
+		t.Errorf("sell orders with %s counted %v times, want 1", service.ErrCodeInsufficientUnits, got)
+	}
+
+	rec = api.do(http.MethodGet, fmt.Sprintf("/sim/v2/ports/%d/holdings", portID), token, nil)
+	var holdings []map[string]interface{}
+	decode(t, rec, &holdings)
+	if len(holdings) != 1 || holdings[0]["fund_code"] != testFundCode || holdings[0]["units"] != "20.00000000" || holdings[0]["cost"] != "200.00" {
+		t.Errorf("holdings = %s", rec.Body)
+	}
+
+	rec = api.do(http.MethodGet, "/sim/v2/orders", token, nil)
+	var orders []map[string]interface{}
+	decode(t, rec, &orders)
+	if len(orders) != 2 {
+		t.Fatalf("got %d orders, want 2: %s", len(orders), rec.Body)
+	}
+	sides := map[interface{}]bool{}
+	for _, o := range orders {
+		sides[o["side"]] = true
+	}
+	if !sides["buy"] || !sides["sell"] {
+		t.Errorf("want one buy and one sell, got %s", rec.Body)
+	}
+
+	// v1 sees the same account in its own shape
+	wallet := api.wallet(token)
+	if wallet.Available.String() != "800" || wallet.InAsset.String() != "200" {
+		t.Errorf("v1 wallet = %s available, %s in assets, want 800 and 200", wallet.Available, wallet.InAsset)
+	}
+}
+
+func TestV2OrderReplay(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.token(7, time.Hour)
+	portID := api.v2OpenAccount(token)
+
+	send := func() *http.Response {
+		req := v2Order(portID, "buy", "100", "10")
+		rec := api.do(http.MethodPost, "/sim/v2/orders", token, req, "Idempotency-Key", "v2-replay")
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("buy: %d %s", rec.Code, rec.Body)
+		}
+		return rec.Result()
+	}
+	first, second := send(), send()
+	if first.Header.Get("Idempotent-Replayed") != "" || second.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Idempotent-Replayed = %q then %q, want \"\" then \"true\"",
+			first.Header.Get("Idempotent-Replayed"), second.Header.Get("Idempotent-Replayed"))
+	}
+	if wallet := api.wallet(token); wallet.Available.String() != "900" {
+		t.Errorf("available = %s after a replayed order, want 900", wallet.Available)
+	}
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/middleware"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+type AdminController interface {
+	GetUserWallet(ctx *gin.Context)
+	GetUserPort(ctx *gin.Context)
+	GetUserOrders(ctx *gin.Context)
+	GetUserAuditLogs(ctx *gin.Context)
+	AdjustBalance(ctx *gin.Context)
+	FreezeAccount(ctx *gin.Context)
+	UnfreezeAccount(ctx *gin.Context)
+}
+
+type adminController struct {
+	adminService       service.AdminService
+	portService        service.PortService
+	walletService      service.WalletService
+	transactionService service.TransactionService
+}
+
+func NewAdminController(admin service.AdminService, port service.PortService, wallet service.WalletService, transaction service.TransactionService) AdminController {
+	return &adminController{
+		adminService:       admin,
+		portService:        port,
+		walletService:      wallet,
+		transactionService: transaction,
+	}
+}
+
+func (c *adminController) GetUserWallet(ctx *gin.Context) {
+	var wallet model.Wallet
+
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.walletService.GetWallet(ctx.Request.Context(), &wallet, userID); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.AdminWallet{Wallet: wallet, IsFrozen: wallet.IsFrozen})
+}
+
+func (c *adminController) GetUserPort(ctx *gin.Context) {
+	var (
+		port        model.Port
+		fundsInPort []model.PortFund
+	)
+
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.portService.GetPort(ctx.Request.Context(), &port, userID); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	if err := c.portService.GetFunds(ctx.Request.Context(), &fundsInPort, port.ID); err != nil {
+		abortWithError(ctx, service.AsError(err, service.ErrCodeDatabase))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"port_id":     port.ID,
+		"port_name":   port.PortName,
+		"uid":         port.UserID,
+		"pl_realized": port.ProfitLossRealized,
+		"sum_cost":    port.AllCost,
+		"funds":       fundsInPort,
+	})
+}
+
+func (c *adminController) GetUserOrders(ctx *gin.Context) {
+	var transList []model.Transaction
+
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.transactionService.Get(ctx.Request.Context(), &transList, userID); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transList)
+}
+
+func (c *adminController) GetUserAuditLogs(ctx *gin.Context) {
+	var logs []model.AuditLog
+
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.adminService.GetAuditLogs(ctx.Request.Context(), &logs, userID); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, logs)
+}
+
+func (c *adminController) AdjustBalance(ctx *gin.Context) {
+	var req dto.AdjustBalanceRequest
+
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, service.NewError(service.ErrCodeInvalidRequest, err))
+		return
+	}
+
+	admin := middleware.Claims(ctx)
+	wallet, err := c.adminService.AdjustBalance(ctx.Request.Context(), admin.UserID, userID, req.Amount, req.Reason)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	logging.FromContext(ctx.Request.Context()).Warnf("Admin %d adjusted balance of user %d by %s: %s", admin.UserID, userID, req.Amount, req.Reason)
+	ctx.JSON(http.StatusOK, wallet)
+}
+
+func (c *adminController) FreezeAccount(ctx *gin.Context) {
+	c.setFrozen(ctx, true)
+}
+
+func (c *adminController) UnfreezeAccount(ctx *gin.Context) {
+	c.setFrozen(ctx, false)
+}
+
+func (c *adminController) setFrozen(ctx *gin.Context, frozen bool) {
+	var req dto.FreezeRequest
+
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, service.NewError(service.ErrCodeInvalidRequest, err))
+		return
+	}
+
+	admin := middleware.Claims(ctx)
+	wallet, err := c.adminService.SetFrozen(ctx.Request.Context(), admin.UserID, userID, frozen, req.Reason)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	logging.FromContext(ctx.Request.Context()).Warnf("Admin %d set frozen=%t on user %d: %s", admin.UserID, frozen, userID, req.Reason)
+	ctx.JSON(http.StatusOK, wallet)
+}
+
+// userIDParam parses the :uid path parameter, aborting the request when it is not a valid ID
+func userIDParam(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("uid"), 10, 32)
+	if err != nil || id == 0 {
+		abortWithError(ctx, service.NewError(service.ErrCodeInvalidRequest, err))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+const (
+	defaultFundSearchLimit = 20
+	maxFundSearchLimit     = 100
+)
+
+type FundController interface {
+	SearchFunds(ctx *gin.Context)
+	GetFund(ctx *gin.Context)
+}
+
+type fundController struct {
+	fundService service.FundService
+}
+
+func NewFundController(fund service.FundService) FundController {
+	return &fundController{
+		fundService: fund,
+	}
+}
+
+func (c *fundController) SearchFunds(ctx *gin.Context) {
+	var (
+		funds []model.Fund
+		limit = defaultFundSearchLimit
+	)
+
+	if l := ctx.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			abortWithError(ctx, service.NewError(service.ErrCodeInvalidRequest, err))
+			return
+		}
+		if n < maxFundSearchLimit {
+			limit = n
+		} else {
+			limit = maxFundSearchLimit
+		}
+	}
+
+	if err := c.fundService.Search(ctx.Request.Context(), &funds, ctx.Query("q"), limit); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, funds)
+}
+
+func (c *fundController) GetFund(ctx *gin.Context) {
+	var fund model.Fund
+
+	if err := c.fundService.GetByCode(ctx.Request.Context(), &fund, ctx.Param("code")); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, fund)
+}
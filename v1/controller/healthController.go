@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"gitlab.com/investio/backend/sim-api/version"
+)
+
+// A readiness probe should not hang on an unreachable database
+const readyTimeout = 2 * time.Second
+
+type HealthController interface {
+	Live(ctx *gin.Context)
+	Ready(ctx *gin.Context)
+	GetVersion(ctx *gin.Context)
+}
+
+type healthController struct {
+	healthService service.HealthService
+}
+
+func NewHealthController(health service.HealthService) HealthController {
+	return &healthController{
+		healthService: health,
+	}
+}
+
+// Live answers as long as the process can handle requests
+func (c *healthController) Live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready fails while the database is unreachable or the server is draining.
+// The probe is public, so the cause is only logged and the reason is fixed.
+func (c *healthController) Ready(ctx *gin.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx.Request.Context(), readyTimeout)
+	defer cancel()
+
+	if err := c.healthService.Ready(pingCtx); err != nil {
+		logging.FromContext(ctx.Request.Context()).Warn("Readiness check failed: ", err)
+		reason := "database unavailable"
+		if errors.Is(err, service.ErrDraining) {
+			reason = "shutting down"
+		}
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "reason": reason})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetVersion reports the build metadata and the applied schema migration
+func (c *healthController) GetVersion(ctx *gin.Context) {
+	info := version.Get()
+	resp := gin.H{
+		"version":    info.Version,
+		"commit":     info.Commit,
+		"build_time": info.BuildTime,
+		"go_version": info.GoVersion,
+	}
+
+	schemaVersion, err := c.healthService.SchemaVersion()
+	if err != nil {
+		logging.FromContext(ctx.Request.Context()).Warn("Read schema version failed: ", err)
+		resp["schema_version"] = nil
+	} else {
+		resp["schema_version"] = schemaVersion
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/v1/openapi"
+)
+
+type OpenAPIController interface {
+	GetSpec(ctx *gin.Context)
+}
+
+type openAPIController struct {
+	spec *openapi.Spec
+}
+
+func NewOpenAPIController(spec *openapi.Spec) OpenAPIController {
+	return &openAPIController{
+		spec: spec,
+	}
+}
+
+// GetSpec serves the OpenAPI document of /sim/v1
+func (c *openAPIController) GetSpec(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", c.spec.JSON())
+}
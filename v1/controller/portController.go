@@ -1,12 +1,10 @@
 package controller
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
-	"github.com/shopspring/decimal"
-	log "github.com/sirupsen/logrus"
+	"gitlab.com/investio/backend/sim-api/logging"
 	"gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/middleware"
 	"gitlab.com/investio/backend/sim-api/v1/model"
 	"gitlab.com/investio/backend/sim-api/v1/service"
 )
@@ -18,18 +16,14 @@ type PortController interface {
 }
 
 type portController struct {
-	authService        service.AuthService
-	portService        service.PortService
-	walletService      service.WalletService
-	transactionService service.TransactionService
+	portService  service.PortService
+	orderService service.OrderService
 }
 
-func NewPortController(auth service.AuthService, port service.PortService, wallet service.WalletService, transaction service.TransactionService) PortController {
+func NewPortController(port service.PortService, order service.OrderService) PortController {
 	return &portController{
-		authService:        auth,
-		portService:        port,
-		walletService:      wallet,
-		transactionService: transaction,
+		portService:  port,
+		orderService: order,
 	}
 }
 
@@ -39,30 +33,19 @@ func (c *portController) GetFundsInPort(ctx *gin.Context) {
 		fundsInPort []model.PortFund
 	)
 
-	// Get access token
-	accessJWT, errReason := c.authService.ValidateAccessToken(ctx.Request)
-	if errReason != "" {
-		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"reason": errReason,
-		})
-		return
-	}
+	accessJWT := middleware.Claims(ctx)
 
-	if err := c.portService.GetPort(&port, accessJWT.UserID); err != nil {
-		port, err = c.portService.CreatePort(accessJWT.UserID)
+	if err := c.portService.GetPort(ctx.Request.Context(), &port, accessJWT.UserID); err != nil {
+		port, err = c.portService.CreatePort(ctx.Request.Context(), accessJWT.UserID)
 		if err != nil {
-			log.Error("CREATE PORT IN GetPort ", err.Error())
-			ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
-				"reason": "Unable to create port",
-			})
+			logging.FromContext(ctx.Request.Context()).Error("CREATE PORT IN GetPort ", err.Error())
+			abortWithError(ctx, service.AsError(err, service.ErrCodeDatabase))
 			return
 		}
 	}
 
-	if err := c.portService.GetFunds(&fundsInPort, port.ID); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
-			"reason": "Unable to get funds in port",
-		})
+	if err := c.portService.GetFunds(ctx.Request.Context(), &fundsInPort, port.ID); err != nil {
+		abortWithError(ctx, service.AsError(err, service.ErrCodeDatabase))
 		return
 	}
 
@@ -76,201 +59,35 @@ func (c *portController) GetFundsInPort(ctx *gin.Context) {
 }
 
 func (c *portController) BuyFund(ctx *gin.Context) {
-	var (
-		req  dto.OrderRequest
-		port model.Port
-	)
+	var req dto.OrderRequest
 
-	// Get access token
-	accessJWT, errReason := c.authService.ValidateAccessToken(ctx.Request)
-	if errReason != "" {
-		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"reason": errReason,
-		})
-		return
-	}
+	accessJWT := middleware.Claims(ctx)
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
-			"reason": "Invalid data provided",
-		})
-		return
-	}
-
-	if err := c.portService.GetPort(&port, accessJWT.UserID); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"reason": "Read port failed",
-		})
-		return
-	}
-
-	if port.ID != req.PortID {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"reason": "Invalid request",
-		})
-		return
-	}
-
-	if err := c.walletService.Purchase(req.Amount, accessJWT.UserID); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"reason": "Purchase failed: " + err.Error(),
-		})
+		abortWithError(ctx, service.NewError(service.ErrCodeInvalidRequest, err))
 		return
 	}
 
-	if err := c.portService.AddOrUpdateFund(req); err != nil {
-		if err := c.walletService.ReversePurchase(req.Amount, accessJWT.UserID); err != nil {
-			log.Error("Critial [AddOrUpdateFund] - <rev> wallet purchase failed ", err.Error())
-			ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
-				"reason": "Critical - <rev> purchase failed: " + err.Error(),
-			})
-			return
-		}
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"reason": "Add/update failed: " + err.Error(),
-		})
+	if _, err := c.orderService.Buy(ctx.Request.Context(), accessJWT.UserID, req); err != nil {
+		abortWithError(ctx, err)
 		return
 	}
 
-	transaction := model.Transaction{
-		DataDate: req.DataDate.ParseTime(),
-		PortID:   req.PortID,
-		FundID:   req.FundID,
-		FundCode: req.FundCode,
-		BcatID:   req.BcatID,
-		Type:     1, // buy
-		UserID:   accessJWT.UserID,
-		NAV:      req.NAV,
-		Amount:   req.Amount,
-		Unit:     req.Unit,
-	}
-	if err := c.transactionService.Write(&transaction); err != nil {
-		if err := c.walletService.ReversePurchase(req.Amount, accessJWT.UserID); err != nil {
-			log.Error("Critial [AddOrUpdateFund] - <rev> wallet purchase failed ", err.Error())
-			ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
-				"reason": "Critical - <rev> purchase failed: " + err.Error(),
-			})
-			return
-		}
-
-		// Reverse purchase fund
-		if err := c.portService.RedeemFund(req); err != nil {
-			log.Error("Critial [AddOrUpdateFund] - <rev> port purchase fund failed ", err.Error())
-			ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
-				"reason": "Critical - <rev> purchase fund failed: " + err.Error(),
-			})
-			return
-		}
-
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"reason": "Add transaction failed: " + err.Error(),
-		})
-		return
-	}
-
-	// log.Info(accessJWT, req.Amount.Mul(decimal.NewFromInt(5)))
 	ctx.Status(200)
 }
 
 func (c *portController) SellFund(ctx *gin.Context) {
-	var (
-		req  dto.OrderRequest
-		port model.Port
-	)
-	// Get access token
-	accessJWT, errReason := c.authService.ValidateAccessToken(ctx.Request)
-	if errReason != "" {
-		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"reason": errReason,
-		})
-		return
-	}
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
-			"reason": "Invalid data provided",
-		})
-		return
-	}
+	var req dto.OrderRequest
 
-	// Validate input
-	if req.Amount.LessThanOrEqual(decimal.NewFromInt32(0)) {
-		ctx.AbortWithStatus(http.StatusBadRequest)
-		return
-	}
+	accessJWT := middleware.Claims(ctx)
 
-	if req.Unit.LessThanOrEqual(decimal.NewFromInt32(0)) {
-		ctx.AbortWithStatus(http.StatusBadRequest)
-		return
-	}
-
-	if err := c.portService.GetPort(&port, accessJWT.UserID); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"reason": "Read port failed",
-		})
-		return
-	}
-
-	if port.ID != req.PortID {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"reason": "Invalid request",
-		})
-		return
-	}
-
-	if err := c.walletService.Redeem(req.Amount, accessJWT.UserID); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"reason": "Redeem failed: " + err.Error(),
-		})
-		return
-	}
-
-	if err := c.portService.RedeemFund(req); err != nil {
-		if err := c.walletService.ReverseRedeem(req.Amount, accessJWT.UserID); err != nil {
-			log.Error("Critial [RedeemFund] - <rev> wallet redeem failed ", err.Error())
-			ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
-				"reason": "Critical - <rev> redeem failed: " + err.Error(),
-			})
-			return
-		}
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"reason": "Redeem fund failed: " + err.Error(),
-		})
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, service.NewError(service.ErrCodeInvalidRequest, err))
 		return
 	}
 
-	transaction := model.Transaction{
-		DataDate: req.DataDate.ParseTime(),
-		PortID:   req.PortID,
-		FundID:   req.FundID,
-		FundCode: req.FundCode,
-		BcatID:   req.BcatID,
-		Type:     2, // buy
-		UserID:   accessJWT.UserID,
-		NAV:      req.NAV,
-		Amount:   req.Amount,
-		Unit:     req.Unit,
-	}
-	if err := c.transactionService.Write(&transaction); err != nil {
-		if err := c.walletService.ReverseRedeem(req.Amount, accessJWT.UserID); err != nil {
-			log.Error("Critial [RedeemFund] - <rev> wallet redeem failed ", err.Error())
-			ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
-				"reason": "Critical - <rev> redeem failed: " + err.Error(),
-			})
-			return
-		}
-		// Reverse redeem fund
-		if err := c.portService.AddOrUpdateFund(req); err != nil {
-			log.Error("Critial [RedeemFund] - <rev> port add/update failed ", err.Error())
-			ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
-				"reason": "Critical - <rev> redeem fund failed: " + err.Error(),
-			})
-			return
-		}
-
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"reason": "Add transaction failed: " + err.Error(),
-		})
+	if _, err := c.orderService.Sell(ctx.Request.Context(), accessJWT.UserID, req); err != nil {
+		abortWithError(ctx, err)
 		return
 	}
 
@@ -291,10 +108,10 @@ func (c *portController) SellFund(ctx *gin.Context) {
 // 		return
 // 	}
 
-// 	if err := c.portService.GetPort(&port, accessJWT.UserID); err != nil {
-// 		port, err = c.portService.CreatePort(accessJWT.UserID)
+// 	if err := c.portService.GetPort(ctx.Request.Context(), &port, accessJWT.UserID); err != nil {
+// 		port, err = c.portService.CreatePort(ctx.Request.Context(), accessJWT.UserID)
 // 		if err != nil {
-// 			log.Error("CREATE PORT IN PiePortUnit ", err.Error())
+// 			logging.FromContext(ctx.Request.Context()).Error("CREATE PORT IN PiePortUnit ", err.Error())
 // 			ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
 // 				"reason": "Unable to create port",
 // 			})
@@ -302,7 +119,7 @@ func (c *portController) SellFund(ctx *gin.Context) {
 // 		}
 // 	}
 
-// 	if err := c.portService.GetFunds(&fundsInPort, port.ID); err != nil {
+// 	if err := c.portService.GetFunds(ctx.Request.Context(), &fundsInPort, port.ID); err != nil {
 // 		ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
 // 			"reason": "Unable to get funds in port",
 // 		})
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/events"
+	"gitlab.com/investio/backend/sim-api/metrics"
+	"gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/middleware"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+const (
+	// streamKeepAlive is how often an idle stream sends a comment, so proxies keep it open
+	streamKeepAlive = 15 * time.Second
+	// maxStreamsPerUser allows a few tabs per user on one instance
+	maxStreamsPerUser = 5
+)
+
+type StreamController interface {
+	Stream(ctx *gin.Context)
+}
+
+type streamController struct {
+	bus              events.Bus
+	walletService    service.WalletService
+	valuationService service.ValuationService
+
+	mu   sync.Mutex
+	open map[uint]int
+}
+
+func NewStreamController(bus events.Bus, wallet service.WalletService, valuation service.ValuationService) StreamController {
+	return &streamController{
+		bus:              bus,
+		walletService:    wallet,
+		valuationService: valuation,
+		open:             make(map[uint]int),
+	}
+}
+
+// Stream sends the wallet and the valuation of the port as they are, then every
+// change to the orders, wallet and valuation of the user as server-sent events
+// until the client disconnects or the server shuts down
+func (c *streamController) Stream(ctx *gin.Context) {
+	userID := middleware.Claims(ctx).UserID
+
+	if !c.acquire(userID) {
+		abortWithError(ctx, service.NewError(service.ErrCodeRateLimited, nil))
+		return
+	}
+	defer c.release(userID)
+
+	// Subscribed before the snapshot is read, so no change in between is missed
+	sub := c.bus.Subscribe(userID)
+	defer sub.Close()
+
+	snapshot, err := c.snapshot(ctx, userID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	metrics.Streams.Inc()
+	defer metrics.Streams.Dec()
+	middleware.MarkStream(ctx)
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Status(200)
+
+	for _, e := range snapshot {
+		if err := writeEvent(ctx.Writer, e); err != nil {
+			return
+		}
+	}
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := writeEvent(ctx.Writer, e); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(ctx.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+// snapshot returns the current wallet and valuation, leaving out the ones the user has not opened yet
+func (c *streamController) snapshot(ctx *gin.Context, userID uint) (snapshot []events.Event, err error) {
+	var wallet model.Wallet
+
+	if err = c.walletService.GetWallet(ctx.Request.Context(), &wallet, userID); err == nil {
+		snapshot = append(snapshot, events.Event{Type: events.TypeWallet, UserID: userID, Data: wallet})
+	} else if service.CodeOf(err) != service.ErrCodeWalletNotFound {
+		return nil, err
+	}
+
+	valuation, err := c.valuationService.Valuate(ctx.Request.Context(), userID)
+	if err == nil {
+		snapshot = append(snapshot, events.Event{Type: events.TypeValuation, UserID: userID, Data: valuation})
+	} else if service.CodeOf(err) != service.ErrCodePortNotFound {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (c *streamController) acquire(userID uint) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.open[userID] >= maxStreamsPerUser {
+		return false
+	}
+	c.open[userID]++
+	return true
+}
+
+func (c *streamController) release(userID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.open[userID]--; c.open[userID] <= 0 {
+		delete(c.open, userID)
+	}
+}
+
+// writeEvent writes e in the text/event-stream format. Snapshot events have no ID,
+// so a client never takes one for a position on the bus.
+func writeEvent(w io.Writer, e events.Event) error {
+	data, err := json.Marshal(eventData(e))
+	if err != nil {
+		return err
+	}
+	if e.ID != 0 {
+		if _, err = fmt.Fprintf(w, "id: %d\n", e.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+	return err
+}
+
+// eventData converts what the services publish to the body of the event
+func eventData(e events.Event) interface{} {
+	switch data := e.Data.(type) {
+	case service.OrderUpdate:
+		return dto.OrderEvent{Status: data.Status, Code: string(data.Code), Order: data.Order}
+	case []model.Fund:
+		navs := make([]dto.FundNAV, 0, len(data))
+		for _, f := range data {
+			navs = append(navs, dto.FundNAV{FundID: f.ID, FundCode: f.Code, NAV: f.NAV.Decimal})
+		}
+		return navs
+	default:
+		return data
+	}
+}
@@ -3,9 +3,8 @@ package controller
 import (
 	"net/http"
 
-	log "github.com/sirupsen/logrus"
-
 	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/v1/middleware"
 	"gitlab.com/investio/backend/sim-api/v1/model"
 	"gitlab.com/investio/backend/sim-api/v1/service"
 )
@@ -15,13 +14,11 @@ type TransactionController interface {
 }
 
 type transactionController struct {
-	authService        service.AuthService
 	transactionService service.TransactionService
 }
 
-func NewTransactionController(auth service.AuthService, transaction service.TransactionService) TransactionController {
+func NewTransactionController(transaction service.TransactionService) TransactionController {
 	return &transactionController{
-		authService:        auth,
 		transactionService: transaction,
 	}
 }
@@ -31,25 +28,12 @@ func (c *transactionController) GetTransaction(ctx *gin.Context) {
 		transList []model.Transaction
 	)
 
-	// Get access token
-	accessJWT, errReason := c.authService.ValidateAccessToken(ctx.Request)
-	if errReason != "" {
-		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"reason": errReason,
-		})
-		return
-	}
+	accessJWT := middleware.Claims(ctx)
 
-	log.Info(transList)
-
-	if err := c.transactionService.Get(&transList, accessJWT.UserID); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
-			"reason": "Unable to get transaction",
-		})
+	if err := c.transactionService.Get(ctx.Request.Context(), &transList, accessJWT.UserID); err != nil {
+		abortWithError(ctx, err)
 		return
 	}
 
-	// log.Info(transList)
-
 	ctx.JSON(http.StatusOK, transList)
 }
@@ -1,10 +1,9 @@
 package controller
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/v1/middleware"
 	"gitlab.com/investio/backend/sim-api/v1/model"
 	"gitlab.com/investio/backend/sim-api/v1/service"
 )
@@ -14,13 +13,11 @@ type WalletController interface {
 }
 
 type walletController struct {
-	authService   service.AuthService
 	walletService service.WalletService
 }
 
-func NewWalletController(auth service.AuthService, wallet service.WalletService) WalletController {
+func NewWalletController(wallet service.WalletService) WalletController {
 	return &walletController{
-		authService:   auth,
 		walletService: wallet,
 	}
 }
@@ -30,22 +27,13 @@ func (c *walletController) GetWallet(ctx *gin.Context) {
 		wallet model.Wallet
 	)
 
-	// Get access token
-	accessJWT, errReason := c.authService.ValidateAccessToken(ctx.Request)
-	if errReason != "" {
-		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"reason": errReason,
-		})
-		return
-	}
+	accessJWT := middleware.Claims(ctx)
 
-	if err := c.walletService.GetWallet(&wallet, accessJWT.UserID); err != nil {
-		wallet, err = c.walletService.CreateWallet(accessJWT.UserID)
+	if err := c.walletService.GetWallet(ctx.Request.Context(), &wallet, accessJWT.UserID); err != nil {
+		wallet, err = c.walletService.CreateWallet(ctx.Request.Context(), accessJWT.UserID)
 		if err != nil {
-			log.Error("CREATE WALLET IN GetWallet ", err.Error())
-			ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
-				"reason": "Unable to create wallet",
-			})
+			logging.FromContext(ctx.Request.Context()).Error("CREATE WALLET IN GetWallet ", err.Error())
+			abortWithError(ctx, service.AsError(err, service.ErrCodeDatabase))
 			return
 		}
 	}
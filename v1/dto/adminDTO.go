@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+)
+
+// AdminWallet is the v1 wallet with the frozen flag, which only admins see
+type AdminWallet struct {
+	model.Wallet
+	IsFrozen bool `json:"is_frozen"`
+}
+
+// AdjustBalanceRequest credits (positive) or debits (negative) the available balance
+type AdjustBalanceRequest struct {
+	Amount decimal.Decimal `json:"amount"`
+	Reason string          `json:"reason"`
+}
+
+type FreezeRequest struct {
+	Reason string `json:"reason"`
+}
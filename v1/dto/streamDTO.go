@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+)
+
+// OrderEvent is the data of an order event on /stream
+type OrderEvent struct {
+	Status string `json:"status"`
+	// Code is the error code of a failed order
+	Code  string            `json:"code,omitempty"`
+	Order model.Transaction `json:"order"`
+}
+
+// Valuation prices the holdings of a port at the latest catalogue NAVs
+type Valuation struct {
+	PortID       uint               `json:"port_id"`
+	Cost         decimal.Decimal    `json:"sum_cost"`
+	MarketValue  decimal.Decimal    `json:"market_value"`
+	PlUnrealized decimal.Decimal    `json:"pl_unrealized"`
+	Funds        []HoldingValuation `json:"funds"`
+}
+
+// HoldingValuation is valued at cost while its fund has no NAV
+type HoldingValuation struct {
+	FundID       string              `json:"fund_id"`
+	FundCode     string              `json:"code"`
+	BcatID       uint8               `json:"bcat_id"`
+	Cost         decimal.Decimal     `json:"cost"`
+	Unit         decimal.Decimal     `json:"unit"`
+	NAV          decimal.NullDecimal `json:"nav"`
+	MarketValue  decimal.Decimal     `json:"market_value"`
+	PlUnrealized decimal.Decimal     `json:"pl_unrealized"`
+}
+
+// FundNAV is one changed fund in a nav event
+type FundNAV struct {
+	FundID   string          `json:"fund_id"`
+	FundCode string          `json:"code"`
+	NAV      decimal.Decimal `json:"nav"`
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/v1/schema"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+const (
+	claimsKey = "sim.claims"
+	authRealm = "sim-api"
+)
+
+// Authenticate validates the access token once per request and stores its claims
+// in the context. Missing or invalid tokens are answered with 401 and a
+// WWW-Authenticate challenge, tokens without authorization with 403.
+func Authenticate(auth service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, err := auth.ValidateAccessToken(ctx.Request)
+		if err != nil {
+			ctx.Header("WWW-Authenticate", challenge(service.CodeOf(err)))
+			_ = ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+
+		if !claims.IsAuthorized {
+			_ = ctx.Error(service.NewError(service.ErrCodeNotAuthorized, nil))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Set(claimsKey, claims)
+		logging.SetUserID(ctx.Request.Context(), claims.UserID)
+		ctx.Next()
+	}
+}
+
+// Claims returns the token claims stored by Authenticate
+func Claims(ctx *gin.Context) *schema.TokenClaims {
+	if v, ok := ctx.Get(claimsKey); ok {
+		return v.(*schema.TokenClaims)
+	}
+	return nil
+}
+
+// challenge builds the RFC 6750 Bearer challenge for an authentication failure
+func challenge(code service.ErrorCode) string {
+	if code == service.ErrCodeTokenMissing {
+		return fmt.Sprintf(`Bearer realm="%s"`, authRealm)
+	}
+	return fmt.Sprintf(`Bearer realm="%s", error="invalid_token", error_description="%s"`, authRealm, code.Message("en"))
+}
+
+// RequireRole allows the request when the token has any of the roles. Use after Authenticate.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims := Claims(ctx)
+		for _, role := range roles {
+			if claims != nil && claims.HasRole(role) {
+				ctx.Next()
+				return
+			}
+		}
+		_ = ctx.Error(service.NewError(service.ErrCodeNotAuthorized, nil))
+		ctx.Abort()
+	}
+}
+
+// RequireScope allows the request when the token has any of the scopes. Use after Authenticate.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims := Claims(ctx)
+		for _, scope := range scopes {
+			if claims != nil && claims.HasScope(scope) {
+				ctx.Next()
+				return
+			}
+		}
+		_ = ctx.Error(service.NewError(service.ErrCodeNotAuthorized, nil))
+		ctx.Abort()
+	}
+}
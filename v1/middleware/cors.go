@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/config"
+)
+
+// CORS answers cross-origin requests from the configured origins.
+// Origins such as https://*.investio.dewkul.me match any subdomain of that
+// domain with the same scheme and port, but not the domain itself.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	corsConfig := cors.Config{
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+
+	allowAll := false
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+	}
+	if allowAll {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		matchers := make([]originMatcher, 0, len(cfg.AllowOrigins))
+		for _, origin := range cfg.AllowOrigins {
+			matchers = append(matchers, newOriginMatcher(origin))
+		}
+		corsConfig.AllowOriginFunc = func(origin string) bool {
+			origin = strings.ToLower(origin)
+			for _, m := range matchers {
+				if m.match(origin) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return cors.New(corsConfig)
+}
+
+// originMatcher compares an Origin header with one allowed origin.
+// A wildcard origin is kept as the part before and after "*".
+type originMatcher struct {
+	exact  string
+	prefix string
+	suffix string
+}
+
+func newOriginMatcher(origin string) originMatcher {
+	origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+	if i := strings.Index(origin, "://*."); i >= 0 {
+		return originMatcher{
+			prefix: origin[:i+len("://")],
+			suffix: origin[i+len("://*"):],
+		}
+	}
+	return originMatcher{exact: origin}
+}
+
+func (m originMatcher) match(origin string) bool {
+	if m.exact != "" {
+		return origin == m.exact
+	}
+	if len(origin) <= len(m.prefix)+len(m.suffix) ||
+		!strings.HasPrefix(origin, m.prefix) || !strings.HasSuffix(origin, m.suffix) {
+		return false
+	}
+	// The subdomain must stay within the host, not swallow a port or path
+	sub := origin[len(m.prefix) : len(origin)-len(m.suffix)]
+	return !strings.ContainsAny(sub, ":/@")
+}
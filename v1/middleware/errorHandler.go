@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+// ErrorResponse is the body of every failed request
+type ErrorResponse struct {
+	Code   service.ErrorCode `json:"code"`
+	Reason string            `json:"reason"`
+}
+
+var statusByCode = map[service.ErrorCode]int{
+	service.ErrCodeInternal:            http.StatusInternalServerError,
+	service.ErrCodeDatabase:            http.StatusBadGateway,
+	service.ErrCodeInvalidRequest:      http.StatusUnprocessableEntity,
+	service.ErrCodeRequestTooLarge:     http.StatusRequestEntityTooLarge,
+	service.ErrCodeInvalidAmount:       http.StatusBadRequest,
+	service.ErrCodeInvalidUnit:         http.StatusBadRequest,
+	service.ErrCodeTokenMissing:        http.StatusUnauthorized,
+	service.ErrCodeTokenInvalid:        http.StatusUnauthorized,
+	service.ErrCodeTokenExpired:        http.StatusUnauthorized,
+	service.ErrCodeTokenMalformed:      http.StatusUnauthorized,
+	service.ErrCodeTokenNotYetValid:    http.StatusUnauthorized,
+	service.ErrCodeTokenIssuedInFuture: http.StatusUnauthorized,
+	service.ErrCodeTokenIssuer:         http.StatusUnauthorized,
+	service.ErrCodeTokenAudience:       http.StatusUnauthorized,
+	service.ErrCodeRefreshToken:        http.StatusUnauthorized,
+	service.ErrCodeNotAuthorized:       http.StatusForbidden,
+	service.ErrCodePortNotFound:        http.StatusNotFound,
+	service.ErrCodePortMismatch:        http.StatusBadRequest,
+	service.ErrCodeWalletNotFound:      http.StatusNotFound,
+	service.ErrCodeAccountFrozen:       http.StatusForbidden,
+	service.ErrCodeReasonRequired:      http.StatusBadRequest,
+	service.ErrCodeHoldingNotFound:     http.StatusBadRequest,
+	service.ErrCodeInsufficientBalance: http.StatusBadRequest,
+	service.ErrCodeInsufficientAsset:   http.StatusBadRequest,
+	service.ErrCodeInsufficientCost:    http.StatusBadRequest,
+	service.ErrCodeInsufficientUnits:   http.StatusBadRequest,
+	service.ErrCodeFundCodeRequired:    http.StatusBadRequest,
+	service.ErrCodeFundNotFound:        http.StatusNotFound,
+	service.ErrCodeFundMismatch:        http.StatusBadRequest,
+	service.ErrCodeFundInactive:        http.StatusBadRequest,
+	service.ErrCodeReversalFailed:      http.StatusBadGateway,
+	service.ErrCodeConcurrentUpdate:    http.StatusConflict,
+	service.ErrCodeRateLimited:         http.StatusTooManyRequests,
+
+	service.ErrCodeIdempotencyKeyInvalid:    http.StatusBadRequest,
+	service.ErrCodeIdempotencyKeyReused:     http.StatusUnprocessableEntity,
+	service.ErrCodeIdempotencyKeyInProgress: http.StatusConflict,
+}
+
+// StatusOf returns the HTTP status for an error code
+func StatusOf(code service.ErrorCode) int {
+	if status, ok := statusByCode[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorHandler writes the last error attached with ctx.Error as an ErrorResponse.
+// Handlers only need to attach the error and abort.
+func ErrorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+
+		err := ctx.Errors.Last().Err
+		code := service.CodeOf(err)
+		status := StatusOf(code)
+		if status >= http.StatusInternalServerError {
+			logging.FromContext(ctx.Request.Context()).WithError(err).Error("Request failed with ", code)
+		}
+
+		ctx.JSON(status, ErrorResponse{
+			Code:   code,
+			Reason: code.Message(language(ctx)),
+		})
+	}
+}
+
+// language picks "th" when Thai is the preferred Accept-Language, otherwise "en"
+func language(ctx *gin.Context) string {
+	accept := ctx.GetHeader("Accept-Language")
+	preferred := strings.TrimSpace(strings.SplitN(accept, ",", 2)[0])
+	if strings.HasPrefix(strings.ToLower(preferred), "th") {
+		return "th"
+	}
+	return "en"
+}
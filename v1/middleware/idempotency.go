@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	defaultReplayResponseType = "application/json; charset=utf-8"
+)
+
+// maxIdempotentBodySize caps the body read for hashing; orders are a few hundred bytes
+const maxIdempotentBodySize = 16 << 10
+
+// Idempotency makes an order safe to retry. The first request sent with an
+// Idempotency-Key header runs and its response is stored; a retry with the same key
+// and body gets that response back, marked with Idempotent-Replayed, and a retry with
+// another body is rejected. Requests without the header run as usual.
+// Use after Authenticate, keys are scoped to the token's UserID.
+//
+// Failures the client is told to retry, such as a server error or losing a race with
+// another order, release the key, so a retry with the same key runs again.
+func Idempotency(idempotency service.IdempotencyService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		claims := Claims(ctx)
+		if key == "" || claims == nil {
+			ctx.Next()
+			return
+		}
+		if !validIdempotencyKey(key) {
+			_ = ctx.Error(service.NewError(service.ErrCodeIdempotencyKeyInvalid, nil))
+			ctx.Abort()
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxIdempotentBodySize))
+		if err != nil {
+			code := service.ErrCodeInvalidRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				code = service.ErrCodeRequestTooLarge
+			}
+			_ = ctx.Error(service.NewError(code, err))
+			ctx.Abort()
+			return
+		}
+		ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		hash := service.HashRequest(ctx.Request.Method, ctx.FullPath(), body)
+		record, replay, err := idempotency.Begin(ctx.Request.Context(), claims.UserID, key, hash)
+		if err != nil {
+			_ = ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+		if replay {
+			replayResponse(ctx, record)
+			return
+		}
+
+		// Storing the outcome must not depend on the client still waiting for it
+		storeCtx := context.WithoutCancel(ctx.Request.Context())
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+
+		defer func() {
+			if p := recover(); p != nil {
+				release(storeCtx, idempotency, &record)
+				panic(p)
+			}
+		}()
+		ctx.Next()
+
+		status := recorder.Status()
+		if len(ctx.Errors) > 0 {
+			code := service.CodeOf(ctx.Errors.Last().Err)
+			status = StatusOf(code)
+			// A server failure or a lost race left nothing done, so let a retry run again.
+			// A failed reversal did leave changes behind and must not be retried.
+			if (status >= http.StatusInternalServerError || retryableCodes[code]) && code != service.ErrCodeReversalFailed {
+				release(storeCtx, idempotency, &record)
+				return
+			}
+			record.ErrorCode = string(code)
+		} else {
+			record.ResponseType = recorder.Header().Get("Content-Type")
+			record.ResponseBody = recorder.body.String()
+		}
+		record.ResponseStatus = status
+
+		if err := idempotency.Complete(storeCtx, &record); err != nil {
+			logging.FromContext(storeCtx).WithError(err).Error("Store idempotent response failed")
+		}
+	}
+}
+
+// retryableCodes are failures below 500 that tell the client to try again
+var retryableCodes = map[service.ErrorCode]bool{
+	service.ErrCodeConcurrentUpdate: true,
+	service.ErrCodeRateLimited:      true,
+}
+
+// replayResponse answers with the stored outcome. Errors go through ErrorHandler
+// again, so the reason is in the language of the retry.
+func replayResponse(ctx *gin.Context, record model.IdempotencyKey) {
+	ctx.Header(IdempotentReplayedHeader, "true")
+	if record.ErrorCode != "" {
+		_ = ctx.Error(service.NewError(service.ErrorCode(record.ErrorCode), nil))
+		ctx.Abort()
+		return
+	}
+	if record.ResponseBody == "" {
+		ctx.AbortWithStatus(record.ResponseStatus)
+		return
+	}
+
+	contentType := record.ResponseType
+	if contentType == "" {
+		contentType = defaultReplayResponseType
+	}
+	ctx.Data(record.ResponseStatus, contentType, []byte(record.ResponseBody))
+	ctx.Abort()
+}
+
+func release(ctx context.Context, idempotency service.IdempotencyService, record *model.IdempotencyKey) {
+	if err := idempotency.Release(ctx, record); err != nil {
+		logging.FromContext(ctx).WithError(err).Error("Release idempotency key failed")
+	}
+}
+
+// validIdempotencyKey accepts 1 to 255 printable ASCII characters
+func validIdempotencyKey(key string) bool {
+	if len(key) == 0 || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// responseRecorder keeps a copy of the body written through it
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/metrics"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+// Metrics records the latency of every request by method, route and status, and how
+// long the requests marked with MarkStream stayed open.
+// Register it before ErrorHandler so the status written for errors is seen.
+func Metrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		// Unmatched paths share one label, so scans cannot blow up the series count
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		if ctx.GetBool(streamKey) {
+			metrics.StreamDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+			return
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+const streamKey = "stream"
+
+// MarkStream tells Metrics that the request turned into a long-lived stream. Requests
+// refused before the stream opens are recorded with the others.
+func MarkStream(ctx *gin.Context) {
+	ctx.Set(streamKey, true)
+}
+
+const orderSideKey = "orderSide"
+
+// OrderMetrics counts the outcome of an order route by the error code it ended with.
+// On a route that takes the side from the body, pass an empty side and have the handler
+// call SetOrderSide; requests rejected before that are counted as metrics.SideUnknown.
+func OrderMetrics(side string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		label := side
+		if label == "" {
+			label = ctx.GetString(orderSideKey)
+		}
+		if label == "" {
+			label = metrics.SideUnknown
+		}
+
+		code := metrics.CodeOK
+		if len(ctx.Errors) > 0 {
+			code = string(service.CodeOf(ctx.Errors.Last().Err))
+		}
+		metrics.Orders.WithLabelValues(label, code).Inc()
+	}
+}
+
+// SetOrderSide names the side of the order for OrderMetrics
+func SetOrderSide(ctx *gin.Context, side string) {
+	ctx.Set(orderSideKey, side)
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/v1/openapi"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+// ValidateRequest checks the parameters and body of a request against its operation in spec
+// and aborts with ErrCodeInvalidRequest when they do not match. Requests the spec does not
+// describe run as usual. Tokens are checked by Authenticate, not here.
+func ValidateRequest(spec *openapi.Spec) gin.HandlerFunc {
+	options := &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc}
+
+	return func(ctx *gin.Context) {
+		route, pathParams, err := spec.FindRoute(ctx.Request)
+		if err != nil {
+			ctx.Next()
+			return
+		}
+
+		// The body is read and put back for the handler
+		err = openapi3filter.ValidateRequest(ctx.Request.Context(), &openapi3filter.RequestValidationInput{
+			Request:    ctx.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		})
+		if err != nil {
+			_ = ctx.Error(service.NewError(service.ErrCodeInvalidRequest, err))
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/ratelimit"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+// Rate limit classes, each with its own bucket per user
+const (
+	RateLimitRead  = "read"
+	RateLimitOrder = "order"
+)
+
+// RateLimit answers 429 with Retry-After once the user has used up the limit of
+// the class. Use after Authenticate; the bucket is keyed by the token's UserID.
+// When the limiter backend fails the request is let through.
+func RateLimit(limiter ratelimit.Limiter, class string, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims := Claims(ctx)
+		if claims == nil || !limit.Enabled() {
+			ctx.Next()
+			return
+		}
+
+		key := fmt.Sprintf("%s:%d", class, claims.UserID)
+		allowed, retryAfter, err := limiter.Allow(ctx.Request.Context(), key, limit)
+		if err != nil {
+			logging.FromContext(ctx.Request.Context()).WithError(err).Warn("Rate limiter unavailable, allowing request")
+			ctx.Next()
+			return
+		}
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			ctx.Header("Retry-After", strconv.Itoa(seconds))
+			_ = ctx.Error(service.NewError(service.ErrCodeRateLimited, nil))
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/logging"
+)
+
+// RequestIDHeader carries the correlation ID between clients, proxies and this API
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestID takes the X-Request-ID of the request, or creates one, echoes it in
+// the response and adds it to the request context for logging.FromContext.
+// Register it first so every later middleware logs with the ID.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if !ValidRequestID(id) {
+			id = NewRequestID()
+		}
+		ctx.Header(RequestIDHeader, id)
+		ctx.Request = ctx.Request.WithContext(logging.WithRequest(ctx.Request.Context(), id, ctx.FullPath()))
+		ctx.Next()
+	}
+}
+
+// RequestLogger writes one access log line per request
+func RequestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		entry := logging.FromContext(ctx.Request.Context()).WithFields(map[string]interface{}{
+			"method":     ctx.Request.Method,
+			"path":       ctx.Request.URL.Path,
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip":  ctx.ClientIP(),
+		})
+		if status >= http.StatusInternalServerError {
+			entry.Error("Request")
+		} else {
+			entry.Info("Request")
+		}
+	}
+}
+
+// ValidRequestID accepts IDs from upstream only when they are short and printable,
+// so they cannot forge log lines
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NewRequestID returns a random 128-bit ID in hex
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/config"
+)
+
+// SecurityHeaders sets HSTS, X-Content-Type-Options and X-Frame-Options on every
+// response. It does nothing unless cfg.Headers is on, since HSTS must only be
+// sent where the API is reachable over HTTPS.
+func SecurityHeaders(cfg config.SecurityConfig) gin.HandlerFunc {
+	if !cfg.Headers {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+
+	var hsts string
+	if cfg.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", int64(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+	frameOptions := strings.ToUpper(cfg.FrameOptions)
+
+	return func(ctx *gin.Context) {
+		h := ctx.Writer.Header()
+		if hsts != "" {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		h.Set("X-Content-Type-Options", "nosniff")
+		if frameOptions != "" {
+			h.Set("X-Frame-Options", frameOptions)
+		}
+		ctx.Next()
+	}
+}
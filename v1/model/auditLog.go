@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Administrative actions recorded in the audit log
+const (
+	AuditActionAdjustBalance = "adjust_balance"
+	AuditActionFreeze        = "freeze"
+	AuditActionUnfreeze      = "unfreeze"
+)
+
+type AuditLog struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	AdminID       uint            `gorm:"index" json:"admin_id"`
+	UserID        uint            `gorm:"index" json:"uid"`
+	Action        string          `gorm:"size:32" json:"action"`
+	Amount        decimal.Decimal `json:"amount" gorm:"type:decimal(12,2);"`
+	BalanceBefore decimal.Decimal `json:"balance_before" gorm:"type:decimal(12,2);"`
+	BalanceAfter  decimal.Decimal `json:"balance_after" gorm:"type:decimal(12,2);"`
+	Reason        string          `json:"reason"`
+	CreatedAt     time.Time       `json:"timestamp"`
+}
+
+// TableName audit_log
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fund status in the catalogue. Only active funds accept new orders.
+const (
+	FundStatusActive   = "active"
+	FundStatusInactive = "inactive"
+)
+
+type Fund struct {
+	ID             string              `gorm:"primaryKey;size:32" json:"fund_id"`
+	Code           string              `gorm:"uniqueIndex;size:64" json:"code"`
+	NameTh         string              `json:"name_th"`
+	NameEn         string              `json:"name_en"`
+	AmcCode        string              `gorm:"size:32" json:"amc_code"`
+	AmcName        string              `json:"amc_name"`
+	BcatID         uint8               `json:"bcat_id"`
+	CategoryName   string              `json:"category_name"`
+	RiskLevel      uint8               `json:"risk_level"`
+	Currency       string              `gorm:"size:3" json:"currency"`
+	DividendPolicy string              `gorm:"size:16" json:"dividend_policy"`
+	Status         string              `gorm:"size:16;index" json:"status"`
+	NAV            decimal.NullDecimal `gorm:"type:decimal(14,4)" json:"nav"` // latest NAV from the catalogue source, null until it sends one
+	CreatedAt      time.Time           `json:"-"`
+	UpdatedAt      time.Time           `json:"-"`
+}
+
+// TableName fund
+func (Fund) TableName() string {
+	return "fund"
+}
+
+func (f Fund) IsActive() bool {
+	return f.Status == FundStatusActive
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// IdempotencyKey remembers an order request sent with an Idempotency-Key header,
+// so a retried request gets the original response instead of placing the order again
+type IdempotencyKey struct {
+	ID     uint   `gorm:"primaryKey"`
+	UserID uint   `gorm:"uniqueIndex:idx_idempotency_key_user_key"`
+	Key    string `gorm:"size:255;uniqueIndex:idx_idempotency_key_user_key"`
+	// RequestHash identifies the method, route and body the key was first used with
+	RequestHash string `gorm:"size:64"`
+	// Completed is false while the first request is still being processed
+	Completed      bool
+	ResponseStatus int
+	ResponseType   string `gorm:"size:128"`
+	ResponseBody   string
+	// ErrorCode is set instead of the body when the request failed
+	ErrorCode string `gorm:"size:64"`
+	CreatedAt time.Time
+	// ExpiresAt ends the lease of an unfinished request, after which a retry takes
+	// the key over, and the memory of a finished one after the TTL
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// TableName idempotency_key
+func (IdempotencyKey) TableName() string {
+	return "idempotency_key"
+}
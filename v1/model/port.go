@@ -15,6 +15,7 @@ type Port struct {
 	// ProfitLostPercent decimal.Decimal `sql:"type:decimal(12,2)"`
 	ProfitLossRealized decimal.Decimal `json:"pl_realized" gorm:"type:decimal(12,2);"`
 	AllCost            decimal.Decimal `json:"sum_cost" gorm:"type:decimal(12,2);"`
+	Version            uint            `gorm:"not null;default:0" json:"-"`
 	CreatedAt          time.Time       `json:"-"`
 	UpdatedAt          time.Time       `json:"-"`
 	DeletedAt          gorm.DeletedAt  `gorm:"index" json:"-"`
@@ -28,12 +29,13 @@ func (Port) TableName() string {
 type PortFund struct {
 	ID         uint            `gorm:"primaryKey" json:"-"`
 	FundID     string          `json:"fund_id"`
-	FundCode   string          `json:"code"`
+	FundCode   string          `gorm:"uniqueIndex:idx_port_fund_port_code,priority:2,length:64" json:"code"`
 	BcatID     uint8           `json:"bcat_id"`
-	PortID     uint            `json:"-"`
+	PortID     uint            `gorm:"uniqueIndex:idx_port_fund_port_code,priority:1" json:"-"`
 	Cost       decimal.Decimal `json:"cost" gorm:"type:decimal(12,2);"`
 	Unit       decimal.Decimal `json:"unit" gorm:"type:decimal(18,8);"`
 	PlRealized decimal.Decimal `json:"pl_realized" gorm:"type:decimal(12,2);"`
+	Version    uint            `gorm:"not null;default:0" json:"-"`
 	CreatedAt  time.Time       `json:"-"`
 	UpdatedAt  time.Time       `json:"-"`
 	DeletedAt  gorm.DeletedAt  `gorm:"index" json:"-"`
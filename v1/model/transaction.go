@@ -7,6 +7,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// Transaction types
+const (
+	TransactionBuy  uint32 = 1
+	TransactionSell uint32 = 2
+)
+
 type Transaction struct {
 	ID        uint            `gorm:"primaryKey" json:"-"`
 	DataDate  time.Time       `json:"data_date" gorm:"type:date;"`
@@ -9,11 +9,13 @@ import (
 
 type Wallet struct {
 	ID           uint            `gorm:"primaryKey" json:"-"`
-	AvaliableBal decimal.Decimal `json:"avalible_bal" gorm:"type:decimal(12,2);"`
+	AvailableBal decimal.Decimal `json:"avalible_bal" gorm:"type:decimal(12,2);"`
 	InOrderBal   decimal.Decimal `json:"inorder_bal" gorm:"type:decimal(12,2);"`
 	InAssetBal   decimal.Decimal `json:"inasset_bal" gorm:"type:decimal(12,2);"`
 	TotalSpend   decimal.Decimal `json:"total_spend" gorm:"type:decimal(12,2);"`
+	IsFrozen     bool            `json:"-"` // not in the v1 body, see dto.AdminWallet and the v2 wallet
 	UserID       uint            `json:"-"`
+	Version      uint            `gorm:"not null;default:0" json:"-"` // optimistic lock, see repository.ErrConflict
 	CreatedAt    time.Time       `json:"-"`
 	UpdatedAt    time.Time       `json:"-"`
 	DeletedAt    gorm.DeletedAt  `gorm:"index" json:"-"`
@@ -0,0 +1,73 @@
+// Package openapi holds the OpenAPI 3 document of the /sim/v1 API.
+// The document is the contract; handlers are tested against it.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+//go:embed openapi.yaml
+var document []byte
+
+// Spec is the parsed document and a router that finds its operations
+type Spec struct {
+	Doc    *openapi3.T
+	json   []byte
+	router routers.Router
+}
+
+// Load parses and validates the embedded document
+func Load() (spec *Spec, err error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(document)
+	if err != nil {
+		return nil, fmt.Errorf("load openapi document: %w", err)
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Spec{Doc: doc, json: data, router: router}, nil
+}
+
+var mustLoad = sync.OnceValue(func() *Spec {
+	spec, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return spec
+})
+
+// MustLoad returns the embedded document, parsed once.
+// It panics when the document is invalid, which the package tests rule out.
+func MustLoad() *Spec {
+	return mustLoad()
+}
+
+// JSON returns the document encoded as JSON
+func (s *Spec) JSON() []byte {
+	return s.json
+}
+
+// FindRoute returns the operation matching the method and path of req and its path parameters.
+// It fails with routers.ErrPathNotFound or routers.ErrMethodNotAllowed when there is none.
+func (s *Spec) FindRoute(req *http.Request) (route *routers.Route, pathParams map[string]string, err error) {
+	return s.router.FindRoute(req)
+}
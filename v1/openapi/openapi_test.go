@@ -0,0 +1,13 @@
+package openapi
+
+import "testing"
+
+func TestLoad(t *testing.T) {
+	spec, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.JSON()) == 0 {
+		t.Error("JSON is empty")
+	}
+}
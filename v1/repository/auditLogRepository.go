@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *model.AuditLog) (err error)
+	// FindByUser returns the latest entries first
+	FindByUser(ctx context.Context, logs *[]model.AuditLog, userID uint, limit int) (err error)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{
+		db: db,
+	}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *model.AuditLog) (err error) {
+	err = r.db.WithContext(ctx).Create(entry).Error
+	return
+}
+
+func (r *auditLogRepository) FindByUser(ctx context.Context, logs *[]model.AuditLog, userID uint, limit int) (err error) {
+	err = r.db.WithContext(ctx).Limit(limit).Where("user_id = ?", userID).Order("created_at desc").Find(logs).Error
+	return
+}
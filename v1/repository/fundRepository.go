@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FundRepository interface {
+	// Upsert inserts new funds and overwrites existing ones with the same fund ID,
+	// keeping the stored NAV of a fund that comes without one. A code the funds give
+	// to another fund ID is taken from the fund that held it, which is removed
+	// from the catalogue unless it is in funds as well.
+	Upsert(ctx context.Context, funds []model.Fund) (err error)
+	FindByCode(ctx context.Context, fund *model.Fund, code string) (err error)
+	// FindByCodes skips codes that are not in the catalogue
+	FindByCodes(ctx context.Context, funds *[]model.Fund, codes []string) (err error)
+	// Search matches the query against code and names, ordered by code
+	Search(ctx context.Context, funds *[]model.Fund, query string, limit int) (err error)
+}
+
+type fundRepository struct {
+	db *gorm.DB
+}
+
+func NewFundRepository(db *gorm.DB) FundRepository {
+	return &fundRepository{
+		db: db,
+	}
+}
+
+// fundUpsertColumns are overwritten by Upsert; created_at is kept and nav only replaced by a new value
+var fundUpsertColumns = []string{
+	"code", "name_th", "name_en", "amc_code", "amc_name", "bcat_id", "category_name",
+	"risk_level", "currency", "dividend_policy", "status", "updated_at",
+}
+
+func (r *fundRepository) Upsert(ctx context.Context, funds []model.Fund) (err error) {
+	// MySQL names the incoming row VALUES() and the stored one by its bare column
+	keepNAV := gorm.Expr("COALESCE(excluded.nav, fund.nav)")
+	if r.db.Dialector.Name() == "mysql" {
+		keepNAV = gorm.Expr("COALESCE(VALUES(nav), nav)")
+	}
+
+	onConflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: append(clause.AssignmentColumns(fundUpsertColumns), clause.Assignment{Column: clause.Column{Name: "nav"}, Value: keepNAV}),
+	}
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := freeReassignedCodes(tx, funds); err != nil {
+			return err
+		}
+		return tx.Clauses(onConflict).CreateInBatches(&funds, 100).Error
+	})
+	return
+}
+
+// freeReassignedCodes clears the unique codes that funds give to another fund ID
+// before they are written. A fund that loses its code is removed, or, when it is
+// in funds with a new code, parked under a placeholder code until it is written.
+func freeReassignedCodes(tx *gorm.DB, funds []model.Fund) error {
+	idByCode := make(map[string]string, len(funds))
+	inLoad := make(map[string]bool, len(funds))
+	for _, f := range funds {
+		idByCode[f.Code] = f.ID
+		inLoad[f.ID] = true
+	}
+	codes := make([]string, 0, len(idByCode))
+	for code := range idByCode {
+		codes = append(codes, code)
+	}
+
+	var holders []model.Fund
+	if err := tx.Select("id", "code").Where("code IN ?", codes).Find(&holders).Error; err != nil {
+		return err
+	}
+	for _, h := range holders {
+		if idByCode[h.Code] == h.ID {
+			continue
+		}
+		var err error
+		if inLoad[h.ID] {
+			err = tx.Model(&model.Fund{}).Where("id = ?", h.ID).Update("code", "moved:"+h.ID).Error
+		} else {
+			err = tx.Delete(&model.Fund{}, "id = ?", h.ID).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fundRepository) FindByCode(ctx context.Context, fund *model.Fund, code string) (err error) {
+	err = notFound(r.db.WithContext(ctx).Where("code = ?", code).First(fund).Error)
+	return
+}
+
+func (r *fundRepository) FindByCodes(ctx context.Context, funds *[]model.Fund, codes []string) (err error) {
+	if len(codes) == 0 {
+		*funds = []model.Fund{}
+		return
+	}
+	err = r.db.WithContext(ctx).Where("code IN ?", codes).Order("code").Find(funds).Error
+	return
+}
+
+// likeEscaper makes %, _ and the escape character itself match literally in a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *fundRepository) Search(ctx context.Context, funds *[]model.Fund, query string, limit int) (err error) {
+	tx := r.db.WithContext(ctx).Limit(limit).Order("code")
+	if query = strings.TrimSpace(query); query != "" {
+		// MySQL reads a backslash in a string literal as an escape of its own
+		escape := `'\'`
+		if r.db.Dialector.Name() == "mysql" {
+			escape = `'\\'`
+		}
+		like := " LIKE ? ESCAPE " + escape
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(query)) + "%"
+		tx = tx.Where("LOWER(code)"+like+" OR LOWER(name_en)"+like+" OR name_th"+like, pattern, pattern, "%"+likeEscaper.Replace(query)+"%")
+	}
+	err = tx.Find(funds).Error
+	return
+}
@@ -0,0 +1,195 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/db"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// backends builds the fund repository on each store
+var backends = map[string]func(t *testing.T) repository.FundRepository{
+	"memory": func(t *testing.T) repository.FundRepository {
+		return repository.NewMemoryStore().Repositories().Funds
+	},
+	"sqlite": func(t *testing.T) repository.FundRepository {
+		return repository.NewFundRepository(openSQLite(t))
+	},
+}
+
+// openSQLite opens a migrated SQLite database
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	gormDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "sim.db")), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := db.NewMigrator(gormDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return gormDB
+}
+
+func nav(value string) decimal.NullDecimal {
+	return decimal.NewNullDecimal(decimal.RequireFromString(value))
+}
+
+func TestFundUpsert(t *testing.T) {
+	for name, newFunds := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			funds := newFunds(t)
+
+			err := funds.Upsert(ctx, []model.Fund{
+				{ID: "M0001_2563", Code: "SIM-SET50", NameEn: "SET50 Index Fund", Status: model.FundStatusActive, NAV: nav("12.5")},
+				{ID: "M0002_2563", Code: "SIM-BOND", NameEn: "Bond Fund", Status: model.FundStatusActive},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// A source that leaves out the NAV keeps the stored one, other fields are replaced
+			err = funds.Upsert(ctx, []model.Fund{
+				{ID: "M0001_2563", Code: "SIM-SET50", NameEn: "SET50 Index Fund (renamed)", Status: model.FundStatusInactive},
+				{ID: "M0002_2563", Code: "SIM-BOND", NameEn: "Bond Fund", Status: model.FundStatusActive, NAV: nav("10.1")},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range []struct {
+				code, name, status string
+				nav                decimal.NullDecimal
+			}{
+				{"SIM-SET50", "SET50 Index Fund (renamed)", model.FundStatusInactive, nav("12.5")},
+				{"SIM-BOND", "Bond Fund", model.FundStatusActive, nav("10.1")},
+			} {
+				var f model.Fund
+				if err := funds.FindByCode(ctx, &f, want.code); err != nil {
+					t.Fatal(err)
+				}
+				if f.NameEn != want.name || f.Status != want.status || f.NAV.Valid != want.nav.Valid || !f.NAV.Decimal.Equal(want.nav.Decimal) {
+					t.Errorf("%s = %q %s at NAV %v, want %q %s at NAV %v", want.code, f.NameEn, f.Status, f.NAV, want.name, want.status, want.nav)
+				}
+			}
+		})
+	}
+}
+
+func TestFundUpsertReassignedCode(t *testing.T) {
+	for name, newFunds := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			funds := newFunds(t)
+
+			err := funds.Upsert(ctx, []model.Fund{
+				{ID: "F1", Code: "SIM-SET50", NameEn: "SET50 Index Fund", Status: model.FundStatusActive, NAV: nav("12.5")},
+				{ID: "F2", Code: "SIM-BOND", NameEn: "Bond Fund", Status: model.FundStatusActive},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// SIM-SET50 moves to a new fund ID and F1 takes a new code, while
+			// SIM-BOND moves to a new fund ID and F2 leaves the catalogue
+			err = funds.Upsert(ctx, []model.Fund{
+				{ID: "F3", Code: "SIM-SET50", NameEn: "SET50 Index Fund (new class)", Status: model.FundStatusActive},
+				{ID: "F1", Code: "SIM-SET50-OLD", NameEn: "SET50 Index Fund", Status: model.FundStatusInactive},
+				{ID: "F4", Code: "SIM-BOND", NameEn: "Bond Fund", Status: model.FundStatusActive},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var all []model.Fund
+			if err := funds.Search(ctx, &all, "", 10); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, f := range all {
+				got = append(got, f.Code+"="+f.ID)
+			}
+			want := []string{"SIM-BOND=F4", "SIM-SET50=F3", "SIM-SET50-OLD=F1"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("catalogue = %v, want %v", got, want)
+			}
+
+			var renamed model.Fund
+			if err := funds.FindByCode(ctx, &renamed, "SIM-SET50-OLD"); err != nil {
+				t.Fatal(err)
+			}
+			if !renamed.NAV.Valid || !renamed.NAV.Decimal.Equal(decimal.RequireFromString("12.5")) {
+				t.Errorf("NAV of the renamed fund = %v, want the stored 12.5", renamed.NAV)
+			}
+		})
+	}
+}
+
+func TestFundSearch(t *testing.T) {
+	for name, newFunds := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			funds := newFunds(t)
+
+			err := funds.Upsert(ctx, []model.Fund{
+				{ID: "F1", Code: "SIM-SET50", NameEn: "SET50 Index Fund", NameTh: "กองทุนดัชนี SET50", Status: model.FundStatusActive},
+				{ID: "F2", Code: "SIM-FIX", NameEn: "Fixed 100% Bond", Status: model.FundStatusActive},
+				{ID: "F3", Code: "SIM_RMF", NameEn: "Retirement Fund", Status: model.FundStatusActive},
+				{ID: "F4", Code: "SIM-A\\B", NameEn: "Mixed Fund", Status: model.FundStatusActive},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, tc := range []struct {
+				query string
+				want  []string
+			}{
+				{"", []string{"SIM-A\\B", "SIM-FIX", "SIM-SET50", "SIM_RMF"}},
+				{"set50", []string{"SIM-SET50"}},
+				{"bond", []string{"SIM-FIX"}},
+				{"ดัชนี", []string{"SIM-SET50"}},
+				// Wildcards of LIKE only match themselves
+				{"%", []string{"SIM-FIX"}},
+				{"100%", []string{"SIM-FIX"}},
+				{"_", []string{"SIM_RMF"}},
+				{"sim_", []string{"SIM_RMF"}},
+				{"\\", []string{"SIM-A\\B"}},
+				{"A\\B", []string{"SIM-A\\B"}},
+			} {
+				var found []model.Fund
+				if err := funds.Search(ctx, &found, tc.query, 10); err != nil {
+					t.Fatalf("Search(%q): %v", tc.query, err)
+				}
+				codes := []string{}
+				for _, f := range found {
+					codes = append(codes, f.Code)
+				}
+				if !reflect.DeepEqual(codes, tc.want) {
+					t.Errorf("Search(%q) = %v, want %v", tc.query, codes, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	// Create inserts the record unless the user already holds the key,
+	// in which case created is false and nothing is written
+	Create(ctx context.Context, record *model.IdempotencyKey) (created bool, err error)
+	FindByKey(ctx context.Context, record *model.IdempotencyKey, userID uint, key string) (err error)
+	// Save updates the stored record, and fails with ErrNotFound when it was deleted meanwhile
+	Save(ctx context.Context, record *model.IdempotencyKey) (err error)
+	Delete(ctx context.Context, id uint) (err error)
+	// DeleteExpired removes every record that expired before the given time
+	DeleteExpired(ctx context.Context, before time.Time) (count int64, err error)
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+func (r *idempotencyRepository) Create(ctx context.Context, record *model.IdempotencyKey) (created bool, err error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *idempotencyRepository) FindByKey(ctx context.Context, record *model.IdempotencyKey, userID uint, key string) (err error) {
+	// A struct condition lets gorm quote "key", a reserved word in MySQL
+	err = notFound(r.db.WithContext(ctx).Where(&model.IdempotencyKey{UserID: userID, Key: key}).First(record).Error)
+	return
+}
+
+func (r *idempotencyRepository) Save(ctx context.Context, record *model.IdempotencyKey) (err error) {
+	result := r.db.WithContext(ctx).Model(record).Select("*").Omit("id", "created_at").Updates(record)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return result.Error
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, id uint) (err error) {
+	err = r.db.WithContext(ctx).Delete(&model.IdempotencyKey{}, id).Error
+	return
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (count int64, err error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
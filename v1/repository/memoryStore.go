@@ -0,0 +1,525 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/investio/backend/sim-api/v1/model"
+)
+
+// MemoryStore keeps every repository in process memory, for tests and local runs without a database.
+// Transactions are serialized and rolled back by restoring a snapshot; reads outside
+// a transaction may observe its uncommitted changes.
+type MemoryStore struct {
+	mu   sync.Mutex
+	txMu sync.Mutex
+	data memoryData
+}
+
+type memoryData struct {
+	lastID       uint
+	ports        map[uint]model.Port
+	portFunds    map[uint]model.PortFund
+	wallets      map[uint]model.Wallet
+	transactions map[uint]model.Transaction
+	funds        map[string]model.Fund
+	auditLogs    map[uint]model.AuditLog
+	idempotency  map[uint]model.IdempotencyKey
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: memoryData{
+			ports:        map[uint]model.Port{},
+			portFunds:    map[uint]model.PortFund{},
+			wallets:      map[uint]model.Wallet{},
+			transactions: map[uint]model.Transaction{},
+			funds:        map[string]model.Fund{},
+			auditLogs:    map[uint]model.AuditLog{},
+			idempotency:  map[uint]model.IdempotencyKey{},
+		},
+	}
+}
+
+// Repositories returns every repository backed by this store
+func (s *MemoryStore) Repositories() Repositories {
+	return Repositories{
+		Ports:        &memoryPortRepository{s},
+		PortFunds:    &memoryPortFundRepository{s},
+		Wallets:      &memoryWalletRepository{s},
+		Transactions: &memoryTransactionRepository{s},
+		Funds:        &memoryFundRepository{s},
+		AuditLogs:    &memoryAuditLogRepository{s},
+		Idempotency:  &memoryIdempotencyRepository{s},
+	}
+}
+
+func (s *MemoryStore) Transaction(ctx context.Context, fn func(repos Repositories) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	snapshot := s.data.clone()
+	s.mu.Unlock()
+
+	if err := fn(s.Repositories()); err != nil {
+		s.mu.Lock()
+		s.data = snapshot
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (d *memoryData) nextID() uint {
+	d.lastID++
+	return d.lastID
+}
+
+func (d memoryData) clone() memoryData {
+	c := memoryData{
+		lastID:       d.lastID,
+		ports:        make(map[uint]model.Port, len(d.ports)),
+		portFunds:    make(map[uint]model.PortFund, len(d.portFunds)),
+		wallets:      make(map[uint]model.Wallet, len(d.wallets)),
+		transactions: make(map[uint]model.Transaction, len(d.transactions)),
+		funds:        make(map[string]model.Fund, len(d.funds)),
+		auditLogs:    make(map[uint]model.AuditLog, len(d.auditLogs)),
+		idempotency:  make(map[uint]model.IdempotencyKey, len(d.idempotency)),
+	}
+	for k, v := range d.ports {
+		c.ports[k] = v
+	}
+	for k, v := range d.portFunds {
+		c.portFunds[k] = v
+	}
+	for k, v := range d.wallets {
+		c.wallets[k] = v
+	}
+	for k, v := range d.transactions {
+		c.transactions[k] = v
+	}
+	for k, v := range d.funds {
+		c.funds[k] = v
+	}
+	for k, v := range d.auditLogs {
+		c.auditLogs[k] = v
+	}
+	for k, v := range d.idempotency {
+		c.idempotency[k] = v
+	}
+	return c
+}
+
+type memoryPortRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryPortRepository) Create(ctx context.Context, port *model.Port) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	port.ID = r.s.data.nextID()
+	port.CreatedAt = time.Now()
+	port.UpdatedAt = port.CreatedAt
+	r.s.data.ports[port.ID] = *port
+	return
+}
+
+func (r *memoryPortRepository) FindByID(ctx context.Context, port *model.Port, id uint) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	p, ok := r.s.data.ports[id]
+	if !ok {
+		return ErrNotFound
+	}
+	*port = p
+	return
+}
+
+func (r *memoryPortRepository) FindByUser(ctx context.Context, port *model.Port, userID uint) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var found *model.Port
+	for _, p := range r.s.data.ports {
+		if p.UserID == userID && (found == nil || p.ID < found.ID) {
+			p := p
+			found = &p
+		}
+	}
+	if found == nil {
+		return ErrNotFound
+	}
+	*port = *found
+	return
+}
+
+func (r *memoryPortRepository) Save(ctx context.Context, port *model.Port) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if port.ID == 0 {
+		port.ID = r.s.data.nextID()
+		port.CreatedAt = time.Now()
+	} else if stored, ok := r.s.data.ports[port.ID]; !ok || stored.Version != port.Version {
+		return ErrConflict
+	} else {
+		port.Version++
+	}
+	port.UpdatedAt = time.Now()
+	r.s.data.ports[port.ID] = *port
+	return
+}
+
+type memoryPortFundRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryPortFundRepository) Create(ctx context.Context, fund *model.PortFund) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, f := range r.s.data.portFunds {
+		if f.PortID == fund.PortID && f.FundCode == fund.FundCode {
+			return ErrConflict
+		}
+	}
+	fund.ID = r.s.data.nextID()
+	fund.CreatedAt = time.Now()
+	fund.UpdatedAt = fund.CreatedAt
+	r.s.data.portFunds[fund.ID] = *fund
+	return
+}
+
+func (r *memoryPortFundRepository) FindByPort(ctx context.Context, funds *[]model.PortFund, portID uint) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	result := []model.PortFund{}
+	for _, f := range r.s.data.portFunds {
+		if f.PortID == portID {
+			result = append(result, f)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	*funds = result
+	return
+}
+
+func (r *memoryPortFundRepository) FindByCode(ctx context.Context, fund *model.PortFund, portID uint, fundCode string) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var found *model.PortFund
+	for _, f := range r.s.data.portFunds {
+		if f.PortID == portID && f.FundCode == fundCode && (found == nil || f.ID < found.ID) {
+			f := f
+			found = &f
+		}
+	}
+	if found == nil {
+		return ErrNotFound
+	}
+	*fund = *found
+	return
+}
+
+func (r *memoryPortFundRepository) Save(ctx context.Context, fund *model.PortFund) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if fund.ID == 0 {
+		fund.ID = r.s.data.nextID()
+		fund.CreatedAt = time.Now()
+	} else if stored, ok := r.s.data.portFunds[fund.ID]; !ok || stored.Version != fund.Version {
+		return ErrConflict
+	} else {
+		fund.Version++
+	}
+	fund.UpdatedAt = time.Now()
+	r.s.data.portFunds[fund.ID] = *fund
+	return
+}
+
+type memoryWalletRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryWalletRepository) Create(ctx context.Context, wallet *model.Wallet) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wallet.ID = r.s.data.nextID()
+	wallet.CreatedAt = time.Now()
+	wallet.UpdatedAt = wallet.CreatedAt
+	r.s.data.wallets[wallet.ID] = *wallet
+	return
+}
+
+func (r *memoryWalletRepository) FindByUser(ctx context.Context, wallet *model.Wallet, userID uint) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var found *model.Wallet
+	for _, w := range r.s.data.wallets {
+		if w.UserID == userID && (found == nil || w.ID < found.ID) {
+			w := w
+			found = &w
+		}
+	}
+	if found == nil {
+		return ErrNotFound
+	}
+	*wallet = *found
+	return
+}
+
+func (r *memoryWalletRepository) Save(ctx context.Context, wallet *model.Wallet) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if wallet.ID == 0 {
+		wallet.ID = r.s.data.nextID()
+		wallet.CreatedAt = time.Now()
+	} else if stored, ok := r.s.data.wallets[wallet.ID]; !ok || stored.Version != wallet.Version {
+		return ErrConflict
+	} else {
+		wallet.Version++
+	}
+	wallet.UpdatedAt = time.Now()
+	r.s.data.wallets[wallet.ID] = *wallet
+	return
+}
+
+type memoryTransactionRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryTransactionRepository) Create(ctx context.Context, tran *model.Transaction) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	tran.ID = r.s.data.nextID()
+	tran.CreatedAt = time.Now()
+	tran.UpdatedAt = tran.CreatedAt
+	r.s.data.transactions[tran.ID] = *tran
+	return
+}
+
+func (r *memoryTransactionRepository) FindByUser(ctx context.Context, transList *[]model.Transaction, userID uint, limit int) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	result := []model.Transaction{}
+	for _, t := range r.s.data.transactions {
+		if t.UserID == userID {
+			result = append(result, t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DataDate.Equal(result[j].DataDate) {
+			return result[i].ID > result[j].ID
+		}
+		return result[i].DataDate.After(result[j].DataDate)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	*transList = result
+	return
+}
+
+type memoryFundRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryFundRepository) Upsert(ctx context.Context, funds []model.Fund) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// A fund that lost its code to another fund ID and is not in the load leaves the catalogue
+	idByCode := make(map[string]string, len(funds))
+	inLoad := make(map[string]bool, len(funds))
+	for _, f := range funds {
+		idByCode[f.Code] = f.ID
+		inLoad[f.ID] = true
+	}
+	for id, f := range r.s.data.funds {
+		if newID, ok := idByCode[f.Code]; ok && newID != id && !inLoad[id] {
+			delete(r.s.data.funds, id)
+		}
+	}
+
+	now := time.Now()
+	for _, f := range funds {
+		if existing, ok := r.s.data.funds[f.ID]; ok {
+			f.CreatedAt = existing.CreatedAt
+			if !f.NAV.Valid {
+				f.NAV = existing.NAV
+			}
+		} else {
+			f.CreatedAt = now
+		}
+		f.UpdatedAt = now
+		r.s.data.funds[f.ID] = f
+	}
+	return
+}
+
+func (r *memoryFundRepository) FindByCode(ctx context.Context, fund *model.Fund, code string) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, f := range r.s.data.funds {
+		if f.Code == code {
+			*fund = f
+			return
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryFundRepository) FindByCodes(ctx context.Context, funds *[]model.Fund, codes []string) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wanted := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		wanted[code] = true
+	}
+	result := []model.Fund{}
+	for _, f := range r.s.data.funds {
+		if wanted[f.Code] {
+			result = append(result, f)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	*funds = result
+	return
+}
+
+func (r *memoryFundRepository) Search(ctx context.Context, funds *[]model.Fund, query string, limit int) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	result := []model.Fund{}
+	for _, f := range r.s.data.funds {
+		if query == "" ||
+			strings.Contains(strings.ToLower(f.Code), query) ||
+			strings.Contains(strings.ToLower(f.NameEn), query) ||
+			strings.Contains(strings.ToLower(f.NameTh), query) {
+			result = append(result, f)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	*funds = result
+	return
+}
+
+type memoryAuditLogRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryAuditLogRepository) Create(ctx context.Context, entry *model.AuditLog) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	entry.ID = r.s.data.nextID()
+	entry.CreatedAt = time.Now()
+	r.s.data.auditLogs[entry.ID] = *entry
+	return
+}
+
+func (r *memoryAuditLogRepository) FindByUser(ctx context.Context, logs *[]model.AuditLog, userID uint, limit int) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	result := []model.AuditLog{}
+	for _, l := range r.s.data.auditLogs {
+		if l.UserID == userID {
+			result = append(result, l)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	*logs = result
+	return
+}
+
+type memoryIdempotencyRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryIdempotencyRepository) Create(ctx context.Context, record *model.IdempotencyKey) (created bool, err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, k := range r.s.data.idempotency {
+		if k.UserID == record.UserID && k.Key == record.Key {
+			return
+		}
+	}
+	record.ID = r.s.data.nextID()
+	record.CreatedAt = time.Now()
+	r.s.data.idempotency[record.ID] = *record
+	created = true
+	return
+}
+
+func (r *memoryIdempotencyRepository) FindByKey(ctx context.Context, record *model.IdempotencyKey, userID uint, key string) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, k := range r.s.data.idempotency {
+		if k.UserID == userID && k.Key == key {
+			*record = k
+			return
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryIdempotencyRepository) Save(ctx context.Context, record *model.IdempotencyKey) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.data.idempotency[record.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	record.CreatedAt = stored.CreatedAt
+	r.s.data.idempotency[record.ID] = *record
+	return
+}
+
+func (r *memoryIdempotencyRepository) Delete(ctx context.Context, id uint) (err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delete(r.s.data.idempotency, id)
+	return
+}
+
+func (r *memoryIdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (count int64, err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for id, k := range r.s.data.idempotency {
+		if k.ExpiresAt.Before(before) {
+			delete(r.s.data.idempotency, id)
+			count++
+		}
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gorm.io/gorm"
+)
+
+type PortFundRepository interface {
+	// Create fails with ErrConflict when the port already holds the fund
+	Create(ctx context.Context, fund *model.PortFund) (err error)
+	FindByPort(ctx context.Context, funds *[]model.PortFund, portID uint) (err error)
+	FindByCode(ctx context.Context, fund *model.PortFund, portID uint, fundCode string) (err error)
+	// Save fails with ErrConflict when the holding changed since it was read
+	Save(ctx context.Context, fund *model.PortFund) (err error)
+}
+
+type portFundRepository struct {
+	db *gorm.DB
+}
+
+func NewPortFundRepository(db *gorm.DB) PortFundRepository {
+	return &portFundRepository{
+		db: db,
+	}
+}
+
+func (r *portFundRepository) Create(ctx context.Context, fund *model.PortFund) (err error) {
+	err = duplicate(r.db, r.db.WithContext(ctx).Create(fund).Error)
+	return
+}
+
+func (r *portFundRepository) FindByPort(ctx context.Context, funds *[]model.PortFund, portID uint) (err error) {
+	err = r.db.WithContext(ctx).Where("port_id = ?", portID).Find(funds).Error
+	return
+}
+
+func (r *portFundRepository) FindByCode(ctx context.Context, fund *model.PortFund, portID uint, fundCode string) (err error) {
+	err = notFound(r.db.WithContext(ctx).Where("fund_code = ?", fundCode).Where("port_id = ?", portID).First(fund).Error)
+	return
+}
+
+func (r *portFundRepository) Save(ctx context.Context, fund *model.PortFund) (err error) {
+	err = saveVersioned(r.db.WithContext(ctx), fund, fund.ID, &fund.Version)
+	return
+}
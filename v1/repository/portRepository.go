@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gorm.io/gorm"
+)
+
+type PortRepository interface {
+	Create(ctx context.Context, port *model.Port) (err error)
+	FindByID(ctx context.Context, port *model.Port, id uint) (err error)
+	FindByUser(ctx context.Context, port *model.Port, userID uint) (err error)
+	// Save fails with ErrConflict when the port changed since it was read
+	Save(ctx context.Context, port *model.Port) (err error)
+}
+
+type portRepository struct {
+	db *gorm.DB
+}
+
+func NewPortRepository(db *gorm.DB) PortRepository {
+	return &portRepository{
+		db: db,
+	}
+}
+
+func (r *portRepository) Create(ctx context.Context, port *model.Port) (err error) {
+	err = r.db.WithContext(ctx).Create(port).Error
+	return
+}
+
+func (r *portRepository) FindByID(ctx context.Context, port *model.Port, id uint) (err error) {
+	err = notFound(r.db.WithContext(ctx).First(port, id).Error)
+	return
+}
+
+func (r *portRepository) FindByUser(ctx context.Context, port *model.Port, userID uint) (err error) {
+	err = notFound(r.db.WithContext(ctx).Where("user_id = ?", userID).First(port).Error)
+	return
+}
+
+func (r *portRepository) Save(ctx context.Context, port *model.Port) (err error) {
+	err = saveVersioned(r.db.WithContext(ctx), port, port.ID, &port.Version)
+	return
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned by every repository when the requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict is returned by Save of a versioned record when the stored version is no longer
+// the one that was read, because another update came first, and by Create when another request
+// created the same record first. Read the record again and retry.
+var ErrConflict = errors.New("record was updated concurrently")
+
+// Repositories is the set of repositories bound to one store, or to one transaction in it
+type Repositories struct {
+	Ports        PortRepository
+	PortFunds    PortFundRepository
+	Wallets      WalletRepository
+	Transactions TransactionRepository
+	Funds        FundRepository
+	AuditLogs    AuditLogRepository
+	Idempotency  IdempotencyRepository
+}
+
+type Transactor interface {
+	// Transaction runs fn with repositories bound to a single transaction.
+	// Returning an error from fn rolls back every change made through them.
+	Transaction(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+// NewGormRepositories builds every repository on the given database
+func NewGormRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Ports:        NewPortRepository(db),
+		PortFunds:    NewPortFundRepository(db),
+		Wallets:      NewWalletRepository(db),
+		Transactions: NewTransactionRepository(db),
+		Funds:        NewFundRepository(db),
+		AuditLogs:    NewAuditLogRepository(db),
+		Idempotency:  NewIdempotencyRepository(db),
+	}
+}
+
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+func NewGormTransactor(db *gorm.DB) Transactor {
+	return &gormTransactor{
+		db: db,
+	}
+}
+
+func (t *gormTransactor) Transaction(ctx context.Context, fn func(repos Repositories) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormRepositories(tx))
+	})
+}
+
+// notFound maps gorm's missing record error to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// duplicate maps a violated unique index to ErrConflict
+func duplicate(db *gorm.DB, err error) error {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+		return ErrConflict
+	}
+	return err
+}
+
+// saveVersioned updates every column of record if the stored row still has the given version,
+// and bumps the version on success. A record without ID is created instead.
+func saveVersioned(db *gorm.DB, record interface{}, id uint, version *uint) error {
+	if id == 0 {
+		return db.Create(record).Error
+	}
+
+	read := *version
+	*version = read + 1
+	result := db.Model(record).Where("version = ?", read).Select("*").Omit("id", "created_at").Updates(record)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrConflict
+	}
+	if result.Error != nil {
+		*version = read
+	}
+	return result.Error
+}
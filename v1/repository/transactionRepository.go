@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gorm.io/gorm"
+)
+
+type TransactionRepository interface {
+	Create(ctx context.Context, tran *model.Transaction) (err error)
+	// FindByUser returns the latest transactions first
+	FindByUser(ctx context.Context, transList *[]model.Transaction, userID uint, limit int) (err error)
+}
+
+type transactionRepository struct {
+	db *gorm.DB
+}
+
+func NewTransactionRepository(db *gorm.DB) TransactionRepository {
+	return &transactionRepository{
+		db: db,
+	}
+}
+
+func (r *transactionRepository) Create(ctx context.Context, tran *model.Transaction) (err error) {
+	err = r.db.WithContext(ctx).Create(tran).Error
+	return
+}
+
+func (r *transactionRepository) FindByUser(ctx context.Context, transList *[]model.Transaction, userID uint, limit int) (err error) {
+	err = r.db.WithContext(ctx).Limit(limit).Where("user_id = ?", userID).Order("data_date desc").Find(transList).Error
+	return
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gorm.io/gorm"
+)
+
+type WalletRepository interface {
+	Create(ctx context.Context, wallet *model.Wallet) (err error)
+	FindByUser(ctx context.Context, wallet *model.Wallet, userID uint) (err error)
+	// Save fails with ErrConflict when the wallet changed since it was read
+	Save(ctx context.Context, wallet *model.Wallet) (err error)
+}
+
+type walletRepository struct {
+	db *gorm.DB
+}
+
+func NewWalletRepository(db *gorm.DB) WalletRepository {
+	return &walletRepository{
+		db: db,
+	}
+}
+
+func (r *walletRepository) Create(ctx context.Context, wallet *model.Wallet) (err error) {
+	err = r.db.WithContext(ctx).Create(wallet).Error
+	return
+}
+
+func (r *walletRepository) FindByUser(ctx context.Context, wallet *model.Wallet, userID uint) (err error) {
+	err = notFound(r.db.WithContext(ctx).Where("user_id = ?", userID).First(wallet).Error)
+	return
+}
+
+func (r *walletRepository) Save(ctx context.Context, wallet *model.Wallet) (err error) {
+	err = saveVersioned(r.db.WithContext(ctx), wallet, wallet.ID, &wallet.Version)
+	return
+}
@@ -1,10 +1,45 @@
 package schema
 
-import "gopkg.in/square/go-jose.v2/jwt"
+import (
+	"strings"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// Roles and scopes understood by the simulator
+const (
+	RoleAdmin       = "admin"
+	ScopeAdminWrite = "sim:admin:write"
+
+	// RoleService marks the tokens of other backend services on the gRPC API
+	RoleService      = "service"
+	ScopeOrdersWrite = "sim:orders:write"
+)
 
 type TokenClaims struct {
-	UserID       uint `json:"user_id"`
-	IsAuthorized bool `json:"is_authorized"`
-	IsRefresh    bool `json:"is_refresh"`
+	UserID       uint     `json:"user_id"`
+	IsAuthorized bool     `json:"is_authorized"`
+	IsRefresh    bool     `json:"is_refresh"`
+	Roles        []string `json:"roles,omitempty"`
+	Scope        string   `json:"scope,omitempty"`
 	*jwt.Claims
 }
+
+func (c *TokenClaims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope checks the space-delimited scope claim (RFC 8693)
+func (c *TokenClaims) HasScope(scope string) bool {
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/events"
+	"gitlab.com/investio/backend/sim-api/tracing"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+)
+
+type AdminService interface {
+	AdjustBalance(ctx context.Context, adminID, userID uint, amount decimal.Decimal, reason string) (wallet model.Wallet, err error)
+	SetFrozen(ctx context.Context, adminID, userID uint, frozen bool, reason string) (wallet model.Wallet, err error)
+	GetAuditLogs(ctx context.Context, logs *[]model.AuditLog, userID uint) (err error)
+}
+
+type adminService struct {
+	auditLogs  repository.AuditLogRepository
+	transactor repository.Transactor
+	events     events.Publisher
+}
+
+func NewAdminService(auditLogs repository.AuditLogRepository, transactor repository.Transactor, publisher events.Publisher) AdminService {
+	return &adminService{
+		auditLogs:  auditLogs,
+		transactor: transactor,
+		events:     publisher,
+	}
+}
+
+// AdjustBalance changes the available balance and records the change in the audit log in one transaction
+func (s *adminService) AdjustBalance(ctx context.Context, adminID, userID uint, amount decimal.Decimal, reason string) (wallet model.Wallet, err error) {
+	ctx, span := tracing.Start(ctx, "adminService.AdjustBalance")
+	defer tracing.End(span, &err)
+
+	if amount.IsZero() {
+		err = NewError(ErrCodeInvalidAmount, nil)
+		return
+	}
+	if reason = strings.TrimSpace(reason); reason == "" {
+		err = NewError(ErrCodeReasonRequired, nil)
+		return
+	}
+
+	// A wallet updated concurrently rolls the transaction back, then it runs again
+	err = retryOnConflict(ctx, func() error {
+		return s.transactor.Transaction(ctx, func(repos repository.Repositories) error {
+			if err := repos.Wallets.FindByUser(ctx, &wallet, userID); err != nil {
+				return notFoundOr(err, ErrCodeWalletNotFound)
+			}
+
+			before := wallet.AvailableBal
+			wallet.AvailableBal = wallet.AvailableBal.Add(amount)
+			if wallet.AvailableBal.LessThan(decimal.NewFromInt(0)) {
+				return NewError(ErrCodeInsufficientBalance, nil)
+			}
+
+			if err := repos.Wallets.Save(ctx, &wallet); err != nil {
+				return NewError(ErrCodeDatabase, err)
+			}
+
+			return s.writeAudit(ctx, repos.AuditLogs, model.AuditLog{
+				AdminID:       adminID,
+				UserID:        userID,
+				Action:        model.AuditActionAdjustBalance,
+				Amount:        amount,
+				BalanceBefore: before,
+				BalanceAfter:  wallet.AvailableBal,
+				Reason:        reason,
+			})
+		})
+	})
+	if err == nil {
+		publishWallet(s.events, wallet)
+	}
+	return
+}
+
+// SetFrozen freezes or unfreezes the simulation account; frozen accounts cannot place orders
+func (s *adminService) SetFrozen(ctx context.Context, adminID, userID uint, frozen bool, reason string) (wallet model.Wallet, err error) {
+	ctx, span := tracing.Start(ctx, "adminService.SetFrozen")
+	defer tracing.End(span, &err)
+
+	if reason = strings.TrimSpace(reason); reason == "" {
+		err = NewError(ErrCodeReasonRequired, nil)
+		return
+	}
+
+	action := model.AuditActionUnfreeze
+	if frozen {
+		action = model.AuditActionFreeze
+	}
+
+	err = retryOnConflict(ctx, func() error {
+		return s.transactor.Transaction(ctx, func(repos repository.Repositories) error {
+			if err := repos.Wallets.FindByUser(ctx, &wallet, userID); err != nil {
+				return notFoundOr(err, ErrCodeWalletNotFound)
+			}
+
+			wallet.IsFrozen = frozen
+			if err := repos.Wallets.Save(ctx, &wallet); err != nil {
+				return NewError(ErrCodeDatabase, err)
+			}
+
+			return s.writeAudit(ctx, repos.AuditLogs, model.AuditLog{
+				AdminID:       adminID,
+				UserID:        userID,
+				Action:        action,
+				Amount:        decimal.NewFromInt(0),
+				BalanceBefore: wallet.AvailableBal,
+				BalanceAfter:  wallet.AvailableBal,
+				Reason:        reason,
+			})
+		})
+	})
+	if err == nil {
+		publishWallet(s.events, wallet)
+	}
+	return
+}
+
+func (s *adminService) GetAuditLogs(ctx context.Context, logs *[]model.AuditLog, userID uint) (err error) {
+	ctx, span := tracing.Start(ctx, "adminService.GetAuditLogs")
+	defer tracing.End(span, &err)
+
+	if err = s.auditLogs.FindByUser(ctx, logs, userID, 100); err != nil {
+		return NewError(ErrCodeDatabase, err)
+	}
+	return
+}
+
+func (s *adminService) writeAudit(ctx context.Context, auditLogs repository.AuditLogRepository, entry model.AuditLog) error {
+	if err := auditLogs.Create(ctx, &entry); err != nil {
+		return NewError(ErrCodeDatabase, err)
+	}
+	return nil
+}
@@ -1,71 +1,104 @@
 package service
 
 import (
-	"crypto/ed25519"
 	"errors"
-	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/investio/backend/sim-api/config"
 	"gitlab.com/investio/backend/sim-api/v1/schema"
 	"gopkg.in/square/go-jose.v2/jwt"
 )
 
 type AuthService interface {
 	DecodeToken(rawJWT string) (parsedJWT *jwt.JSONWebToken, result *schema.TokenClaims, err error)
+	ValidateClaims(payload *schema.TokenClaims) (err error)
 	IsExpired(payload *schema.TokenClaims) (exp bool, diff float64)
 	ExtractHeader(r *http.Request) string
-	ValidateAccessToken(r *http.Request) (accessJwt *schema.TokenClaims, errReason string)
+	ValidateAccessToken(r *http.Request) (accessJwt *schema.TokenClaims, err error)
+	ValidateToken(rawJWT string) (accessJWT *schema.TokenClaims, err error)
 }
 
 type authService struct {
-	PrivateKey ed25519.PrivateKey
-	PublicKey  ed25519.PublicKey
+	keys       KeySet
+	validation config.AuthConfig
 }
 
-func NewAuthService() AuthService {
-	return &authService{}
+// NewAuthService verifies tokens with keys and checks them against the configured
+// issuer and audience. An empty Issuer or Audience skips that check; Leeway absorbs
+// clock skew on exp, nbf and iat.
+func NewAuthService(keys KeySet, validation config.AuthConfig) AuthService {
+	return &authService{
+		keys:       keys,
+		validation: validation,
+	}
 }
 
 // Parse & validate token
 func (s *authService) DecodeToken(rawJWT string) (parsedJWT *jwt.JSONWebToken, result *schema.TokenClaims, err error) {
-	// First attempt
-	if s.PublicKey == nil {
-		err = s.setKey()
-		if err != nil {
-			log.Println("Cannot read .key file: ", err)
-			return
-		}
-	}
 	parsedJWT, err = jwt.ParseSigned(rawJWT)
 	if err != nil {
-		log.Println("Failed to get claims JWT: ", err)
+		log.Debug("Failed to get claims JWT: ", err)
+		err = NewError(ErrCodeTokenMalformed, err)
 		return
 	}
-	result = &schema.TokenClaims{}
-	err = parsedJWT.Claims(s.PublicKey, result)
-	return
-}
 
-func (s *authService) setKey() (err error) {
-	// Read seed
-	seed, err := ioutil.ReadFile("./keys/token.key")
+	var kid string
+	if len(parsedJWT.Headers) > 0 {
+		kid = parsedJWT.Headers[0].KeyID
+	}
+	key, err := s.keys.Key(kid)
 	if err != nil {
+		err = NewError(ErrCodeTokenInvalid, err)
 		return
 	}
-	var ok bool
-	// Create the key from seed
-	s.PrivateKey = ed25519.NewKeyFromSeed(seed)
-	s.PublicKey, ok = s.PrivateKey.Public().(ed25519.PublicKey)
-	if !ok {
-		err = errors.New("problem with casting public key to ed25519 public key")
+
+	result = &schema.TokenClaims{}
+	if err = parsedJWT.Claims(key, result); err != nil {
+		err = NewError(ErrCodeTokenInvalid, err)
 	}
 	return
 }
 
+// ValidateClaims checks the registered claims against the configured expectations.
+// A token must carry exp; nbf and iat are checked when present.
+func (s *authService) ValidateClaims(payload *schema.TokenClaims) (err error) {
+	if payload.Claims == nil || payload.Expiry == nil {
+		return NewError(ErrCodeTokenMalformed, errors.New("missing exp claim"))
+	}
+
+	expected := jwt.Expected{
+		Issuer:   s.validation.Issuer,
+		Audience: jwt.Audience(s.validation.Audience),
+		Time:     time.Now(),
+	}
+	err = payload.Claims.ValidateWithLeeway(expected, s.validation.Leeway)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, jwt.ErrExpired):
+		return NewError(ErrCodeTokenExpired, err)
+	case errors.Is(err, jwt.ErrNotValidYet):
+		return NewError(ErrCodeTokenNotYetValid, err)
+	case errors.Is(err, jwt.ErrIssuedInTheFuture):
+		return NewError(ErrCodeTokenIssuedInFuture, err)
+	case errors.Is(err, jwt.ErrInvalidIssuer):
+		return NewError(ErrCodeTokenIssuer, err)
+	case errors.Is(err, jwt.ErrInvalidAudience):
+		return NewError(ErrCodeTokenAudience, err)
+	default:
+		return NewError(ErrCodeTokenInvalid, err)
+	}
+}
+
+// IsExpired reports a token without exp as expired
 func (s *authService) IsExpired(payload *schema.TokenClaims) (exp bool, diff float64) {
+	if payload.Claims == nil || payload.Expiry == nil {
+		return true, 0
+	}
+
 	now := time.Now()
 	expired := payload.Expiry.Time()
 
@@ -86,33 +119,31 @@ func (s *authService) ExtractHeader(r *http.Request) string {
 	return ""
 }
 
-func (s *authService) ValidateAccessToken(r *http.Request) (accessJWT *schema.TokenClaims, errReason string) {
-	var err error
+func (s *authService) ValidateAccessToken(r *http.Request) (accessJWT *schema.TokenClaims, err error) {
 	// Get access token
 	accessToken := s.ExtractHeader(r)
 
 	if accessToken == "" {
-		errReason = "Token is empty"
+		err = NewError(ErrCodeTokenMissing, nil)
 		return
 	}
+	return s.ValidateToken(accessToken)
+}
 
-	_, accessJWT, err = s.DecodeToken(accessToken)
+// ValidateToken decodes an access token and checks its claims. Refresh tokens are refused.
+func (s *authService) ValidateToken(rawJWT string) (accessJWT *schema.TokenClaims, err error) {
+	_, accessJWT, err = s.DecodeToken(rawJWT)
 	if err != nil {
-		errReason = err.Error()
 		return
 	}
 
-	if accessJWT.IsRefresh {
-		errReason = "Token is invalid"
+	if err = s.ValidateClaims(accessJWT); err != nil {
 		return
 	}
 
-	isExp, _ := s.IsExpired(accessJWT)
-	if isExp {
-		errReason = "Token expired"
-
+	if accessJWT.IsRefresh {
+		err = NewError(ErrCodeRefreshToken, nil)
 	}
 
 	return
-
 }
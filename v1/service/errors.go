@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+)
+
+// ErrorCode is a stable, machine-readable identifier of a failure.
+// Clients match on the code, never on the message.
+type ErrorCode string
+
+const (
+	ErrCodeInternal            ErrorCode = "INTERNAL_ERROR"
+	ErrCodeDatabase            ErrorCode = "DATABASE_ERROR"
+	ErrCodeInvalidRequest      ErrorCode = "INVALID_REQUEST"
+	ErrCodeRequestTooLarge     ErrorCode = "REQUEST_TOO_LARGE"
+	ErrCodeInvalidAmount       ErrorCode = "INVALID_AMOUNT"
+	ErrCodeInvalidUnit         ErrorCode = "INVALID_UNIT"
+	ErrCodeTokenMissing        ErrorCode = "TOKEN_MISSING"
+	ErrCodeTokenInvalid        ErrorCode = "TOKEN_INVALID"
+	ErrCodeTokenExpired        ErrorCode = "TOKEN_EXPIRED"
+	ErrCodeTokenMalformed      ErrorCode = "TOKEN_MALFORMED"
+	ErrCodeTokenNotYetValid    ErrorCode = "TOKEN_NOT_YET_VALID"
+	ErrCodeTokenIssuedInFuture ErrorCode = "TOKEN_ISSUED_IN_FUTURE"
+	ErrCodeTokenIssuer         ErrorCode = "TOKEN_INVALID_ISSUER"
+	ErrCodeTokenAudience       ErrorCode = "TOKEN_INVALID_AUDIENCE"
+	ErrCodeRefreshToken        ErrorCode = "REFRESH_TOKEN_NOT_ALLOWED"
+	ErrCodeNotAuthorized       ErrorCode = "NOT_AUTHORIZED"
+	ErrCodePortNotFound        ErrorCode = "PORT_NOT_FOUND"
+	ErrCodePortMismatch        ErrorCode = "PORT_MISMATCH"
+	ErrCodeWalletNotFound      ErrorCode = "WALLET_NOT_FOUND"
+	ErrCodeAccountFrozen       ErrorCode = "ACCOUNT_FROZEN"
+	ErrCodeReasonRequired      ErrorCode = "REASON_REQUIRED"
+	ErrCodeHoldingNotFound     ErrorCode = "HOLDING_NOT_FOUND"
+	ErrCodeInsufficientBalance ErrorCode = "INSUFFICIENT_BALANCE"
+	ErrCodeInsufficientAsset   ErrorCode = "INSUFFICIENT_ASSET_BALANCE"
+	ErrCodeInsufficientCost    ErrorCode = "INSUFFICIENT_COST"
+	ErrCodeInsufficientUnits   ErrorCode = "INSUFFICIENT_UNITS"
+	ErrCodeFundCodeRequired    ErrorCode = "FUND_CODE_REQUIRED"
+	ErrCodeFundNotFound        ErrorCode = "FUND_NOT_FOUND"
+	ErrCodeFundMismatch        ErrorCode = "FUND_MISMATCH"
+	ErrCodeFundInactive        ErrorCode = "FUND_INACTIVE"
+	ErrCodeReversalFailed      ErrorCode = "REVERSAL_FAILED"
+	ErrCodeConcurrentUpdate    ErrorCode = "CONCURRENT_UPDATE"
+	ErrCodeRateLimited         ErrorCode = "RATE_LIMITED"
+
+	ErrCodeIdempotencyKeyInvalid    ErrorCode = "IDEMPOTENCY_KEY_INVALID"
+	ErrCodeIdempotencyKeyReused     ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	ErrCodeIdempotencyKeyInProgress ErrorCode = "IDEMPOTENCY_KEY_IN_PROGRESS"
+)
+
+// Message is the human-readable text of an error code
+type Message struct {
+	En string
+	Th string
+}
+
+var messages = map[ErrorCode]Message{
+	ErrCodeInternal:            {"Something went wrong", "เกิดข้อผิดพลาดภายในระบบ"},
+	ErrCodeDatabase:            {"Unable to access data", "ไม่สามารถเข้าถึงข้อมูลได้"},
+	ErrCodeInvalidRequest:      {"Invalid data provided", "ข้อมูลไม่ถูกต้อง"},
+	ErrCodeRequestTooLarge:     {"Request is too large", "คำขอมีขนาดใหญ่เกินไป"},
+	ErrCodeInvalidAmount:       {"Amount must be greater than zero", "จำนวนเงินต้องมากกว่าศูนย์"},
+	ErrCodeInvalidUnit:         {"Unit must be greater than zero", "จำนวนหน่วยต้องมากกว่าศูนย์"},
+	ErrCodeTokenMissing:        {"Token is empty", "ไม่พบโทเคน"},
+	ErrCodeTokenInvalid:        {"Token is invalid", "โทเคนไม่ถูกต้อง"},
+	ErrCodeTokenExpired:        {"Token expired", "โทเคนหมดอายุ"},
+	ErrCodeTokenMalformed:      {"Token is malformed", "รูปแบบโทเคนไม่ถูกต้อง"},
+	ErrCodeTokenNotYetValid:    {"Token is not valid yet", "โทเคนยังไม่สามารถใช้งานได้"},
+	ErrCodeTokenIssuedInFuture: {"Token is issued in the future", "เวลาออกโทเคนไม่ถูกต้อง"},
+	ErrCodeTokenIssuer:         {"Token is from an unknown issuer", "ผู้ออกโทเคนไม่ถูกต้อง"},
+	ErrCodeTokenAudience:       {"Token is not intended for this service", "โทเคนไม่ได้ออกให้บริการนี้"},
+	ErrCodeRefreshToken:        {"Refresh token cannot be used here", "ไม่สามารถใช้รีเฟรชโทเคนได้"},
+	ErrCodeNotAuthorized:       {"You are not allowed to do this", "คุณไม่มีสิทธิ์ทำรายการนี้"},
+	ErrCodePortNotFound:        {"Port not found", "ไม่พบพอร์ต"},
+	ErrCodePortMismatch:        {"Port does not belong to this user", "พอร์ตไม่ใช่ของผู้ใช้นี้"},
+	ErrCodeWalletNotFound:      {"Wallet not found", "ไม่พบกระเป๋าเงิน"},
+	ErrCodeAccountFrozen:       {"Simulation account is frozen", "บัญชีจำลองถูกระงับ"},
+	ErrCodeReasonRequired:      {"Reason is required", "กรุณาระบุเหตุผล"},
+	ErrCodeHoldingNotFound:     {"Fund is not in the port", "ไม่พบกองทุนนี้ในพอร์ต"},
+	ErrCodeInsufficientBalance: {"Available balance is not enough", "ยอดเงินคงเหลือไม่เพียงพอ"},
+	ErrCodeInsufficientAsset:   {"In-asset balance is not enough", "มูลค่าสินทรัพย์ไม่เพียงพอ"},
+	ErrCodeInsufficientCost:    {"Amount is more than the cost of the holding", "จำนวนเงินมากกว่าต้นทุนที่ถือ"},
+	ErrCodeInsufficientUnits:   {"Unit is more than the units held", "จำนวนหน่วยมากกว่าที่ถือ"},
+	ErrCodeFundCodeRequired:    {"Fund code is required", "กรุณาระบุรหัสกองทุน"},
+	ErrCodeFundNotFound:        {"Fund not found", "ไม่พบกองทุน"},
+	ErrCodeFundMismatch:        {"Fund ID does not match fund code", "รหัสกองทุนไม่ตรงกัน"},
+	ErrCodeFundInactive:        {"Fund is not open for orders", "กองทุนไม่เปิดรับคำสั่งซื้อขาย"},
+	ErrCodeReversalFailed:      {"Order failed and could not be reversed", "คำสั่งล้มเหลวและไม่สามารถย้อนกลับรายการได้"},
+	ErrCodeConcurrentUpdate:    {"Account is busy with another order, please try again", "บัญชีกำลังทำรายการอื่นอยู่ กรุณาลองใหม่อีกครั้ง"},
+	ErrCodeRateLimited:         {"Too many requests, please try again later", "ส่งคำขอบ่อยเกินไป กรุณาลองใหม่ภายหลัง"},
+
+	ErrCodeIdempotencyKeyInvalid:    {"Idempotency-Key must be 1 to 255 printable characters", "Idempotency-Key ต้องเป็นตัวอักษร 1 ถึง 255 ตัว"},
+	ErrCodeIdempotencyKeyReused:     {"Idempotency-Key was already used with a different request", "Idempotency-Key นี้ถูกใช้กับคำขออื่นแล้ว"},
+	ErrCodeIdempotencyKeyInProgress: {"A request with this Idempotency-Key is still being processed", "คำขอที่ใช้ Idempotency-Key นี้กำลังดำเนินการอยู่"},
+}
+
+// Message returns the text of the code in the given language ("th" or "en").
+// English is used when the language is unknown.
+func (c ErrorCode) Message(lang string) string {
+	msg, ok := messages[c]
+	if !ok {
+		msg = messages[ErrCodeInternal]
+	}
+	if lang == "th" {
+		return msg.Th
+	}
+	return msg.En
+}
+
+// Error is a service failure with a stable code.
+// Err is the underlying cause; it is logged but never sent to clients.
+type Error struct {
+	Code ErrorCode
+	Err  error
+}
+
+func NewError(code ErrorCode, cause error) *Error {
+	return &Error{
+		Code: code,
+		Err:  cause,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	}
+	return string(e.Code)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// AsError returns err when it is already a service error,
+// otherwise wraps it with the given code
+func AsError(err error, code ErrorCode) *Error {
+	var svcErr *Error
+	if errors.As(err, &svcErr) {
+		return svcErr
+	}
+	return NewError(code, err)
+}
+
+// CodeOf returns the code of a service error, or ErrCodeInternal for any other error
+func CodeOf(err error) ErrorCode {
+	var svcErr *Error
+	if errors.As(err, &svcErr) {
+		return svcErr.Code
+	}
+	return ErrCodeInternal
+}
+
+// notFoundOr maps a missing record to the given code and any other failure to ErrCodeDatabase
+func notFoundOr(err error, code ErrorCode) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return NewError(code, err)
+	}
+	return NewError(ErrCodeDatabase, err)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/events"
+	"gitlab.com/investio/backend/sim-api/tracing"
+	"gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+)
+
+type FundService interface {
+	LoadFromFile(ctx context.Context, path string) (count int, err error)
+	LoadFromAPI(ctx context.Context, url string) (count int, err error)
+	Search(ctx context.Context, funds *[]model.Fund, query string, limit int) (err error)
+	GetByCode(ctx context.Context, fund *model.Fund, code string) (err error)
+	ValidateOrder(ctx context.Context, req *dto.OrderRequest) (err error)
+}
+
+type fundService struct {
+	funds      repository.FundRepository
+	httpClient *http.Client
+	events     events.Publisher
+}
+
+// NewFundService publishes the funds whose NAV changed after every catalogue load
+func NewFundService(funds repository.FundRepository, publisher events.Publisher) FundService {
+	return &fundService{
+		funds:      funds,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		events:     publisher,
+	}
+}
+
+// LoadFromFile upserts the catalogue from a JSON seed file (an array of model.Fund)
+func (s *fundService) LoadFromFile(ctx context.Context, path string) (count int, err error) {
+	ctx, span := tracing.Start(ctx, "fundService.LoadFromFile")
+	defer tracing.End(span, &err)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	return s.load(ctx, data)
+}
+
+// LoadFromAPI upserts the catalogue from the upstream fund API,
+// which responds with the same JSON array as the seed file.
+func (s *fundService) LoadFromAPI(ctx context.Context, url string) (count int, err error) {
+	ctx, span := tracing.Start(ctx, "fundService.LoadFromAPI")
+	defer tracing.End(span, &err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("fund api responded %d", resp.StatusCode)
+		return
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+	return s.load(ctx, data)
+}
+
+func (s *fundService) load(ctx context.Context, data []byte) (count int, err error) {
+	var funds []model.Fund
+	if err = json.Unmarshal(data, &funds); err != nil {
+		return
+	}
+
+	for i := range funds {
+		funds[i].Code = strings.TrimSpace(funds[i].Code)
+		if funds[i].ID == "" || funds[i].Code == "" {
+			err = fmt.Errorf("fund at index %d has no fund_id or code", i)
+			return
+		}
+		if funds[i].Status == "" {
+			funds[i].Status = model.FundStatusActive
+		}
+	}
+
+	if len(funds) == 0 {
+		return
+	}
+
+	changed, err := s.navChanges(ctx, funds)
+	if err != nil {
+		return
+	}
+	if err = s.funds.Upsert(ctx, funds); err != nil {
+		return
+	}
+	count = len(funds)
+
+	if len(changed) > 0 {
+		s.events.Publish(events.Event{Type: events.TypeNAV, Data: changed})
+	}
+	return
+}
+
+// navChanges returns the funds of a catalogue load whose NAV differs from the stored one
+func (s *fundService) navChanges(ctx context.Context, funds []model.Fund) (changed []model.Fund, err error) {
+	codes := make([]string, len(funds))
+	for i, f := range funds {
+		codes[i] = f.Code
+	}
+	var stored []model.Fund
+	if err = s.funds.FindByCodes(ctx, &stored, codes); err != nil {
+		return
+	}
+	navs := make(map[string]decimal.NullDecimal, len(stored))
+	for _, f := range stored {
+		navs[f.Code] = f.NAV
+	}
+
+	for _, f := range funds {
+		if !f.NAV.Valid {
+			continue
+		}
+		if old := navs[f.Code]; !old.Valid || !old.Decimal.Equal(f.NAV.Decimal) {
+			changed = append(changed, f)
+		}
+	}
+	return
+}
+
+func (s *fundService) Search(ctx context.Context, funds *[]model.Fund, query string, limit int) (err error) {
+	ctx, span := tracing.Start(ctx, "fundService.Search")
+	defer tracing.End(span, &err)
+
+	if err = s.funds.Search(ctx, funds, query, limit); err != nil {
+		return NewError(ErrCodeDatabase, err)
+	}
+	return
+}
+
+func (s *fundService) GetByCode(ctx context.Context, fund *model.Fund, code string) (err error) {
+	ctx, span := tracing.Start(ctx, "fundService.GetByCode")
+	defer tracing.End(span, &err)
+
+	if err = s.funds.FindByCode(ctx, fund, code); err != nil {
+		return notFoundOr(err, ErrCodeFundNotFound)
+	}
+	return
+}
+
+// ValidateOrder checks the ordered fund against the catalogue and replaces
+// the client-supplied fund ID and category with the catalogue values
+func (s *fundService) ValidateOrder(ctx context.Context, req *dto.OrderRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "fundService.ValidateOrder")
+	defer tracing.End(span, &err)
+
+	var fund model.Fund
+
+	if req.FundCode == "" {
+		return NewError(ErrCodeFundCodeRequired, nil)
+	}
+
+	if err = s.GetByCode(ctx, &fund, req.FundCode); err != nil {
+		return
+	}
+
+	if req.FundID != "" && req.FundID != fund.ID {
+		return NewError(ErrCodeFundMismatch, nil)
+	}
+
+	if !fund.IsActive() {
+		return NewError(ErrCodeFundInactive, nil)
+	}
+
+	req.FundID = fund.ID
+	req.BcatID = fund.BcatID
+	return
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrDraining means the server is shutting down and takes no new traffic
+var ErrDraining = errors.New("server is shutting down")
+
+type HealthService interface {
+	// Ready reports whether the server can serve requests, which needs the database
+	Ready(ctx context.Context) (err error)
+	// Drain marks the server as shutting down, so readiness fails from now on
+	Drain()
+	// SchemaVersion returns the latest applied schema migration
+	SchemaVersion() (version int, err error)
+}
+
+// SchemaVersioner reports the applied schema migration, see db.Migrator
+type SchemaVersioner interface {
+	Version() (version int, err error)
+}
+
+type healthService struct {
+	db       *sql.DB
+	schema   SchemaVersioner
+	draining int32
+}
+
+func NewHealthService(db *sql.DB, schema SchemaVersioner) HealthService {
+	return &healthService{
+		db:     db,
+		schema: schema,
+	}
+}
+
+func (s *healthService) Ready(ctx context.Context) (err error) {
+	if atomic.LoadInt32(&s.draining) == 1 {
+		return ErrDraining
+	}
+	return s.db.PingContext(ctx)
+}
+
+func (s *healthService) Drain() {
+	atomic.StoreInt32(&s.draining, 1)
+}
+
+func (s *healthService) SchemaVersion() (version int, err error) {
+	return s.schema.Version()
+}
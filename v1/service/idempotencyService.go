@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gitlab.com/investio/backend/sim-api/config"
+	"gitlab.com/investio/backend/sim-api/tracing"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+)
+
+type IdempotencyService interface {
+	// Begin claims the key for a request, for the lease. When the key was used before with
+	// the same request, replay is true and record holds the original outcome. A key whose
+	// request did not finish within its lease is claimed again.
+	Begin(ctx context.Context, userID uint, key string, requestHash string) (record model.IdempotencyKey, replay bool, err error)
+	// Complete stores the outcome of the request that claimed the key, to be replayed until the TTL
+	Complete(ctx context.Context, record *model.IdempotencyKey) (err error)
+	// Release forgets the key, so that a retry runs the request again
+	Release(ctx context.Context, record *model.IdempotencyKey) (err error)
+	PurgeExpired(ctx context.Context) (count int64, err error)
+}
+
+type idempotencyService struct {
+	keys  repository.IdempotencyRepository
+	ttl   time.Duration
+	lease time.Duration
+}
+
+func NewIdempotencyService(keys repository.IdempotencyRepository, cfg config.IdempotencyConfig) IdempotencyService {
+	return &idempotencyService{
+		keys:  keys,
+		ttl:   cfg.TTL,
+		lease: cfg.Lease,
+	}
+}
+
+// HashRequest identifies a request by its method, route and body. A JSON body is
+// compacted first, so whitespace alone does not make two requests differ.
+func HashRequest(method, route string, body []byte) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, body); err == nil {
+		body = compact.Bytes()
+	}
+
+	h := sha256.New()
+	h.Write([]byte(method + " " + route + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, userID uint, key string, requestHash string) (record model.IdempotencyKey, replay bool, err error) {
+	ctx, span := tracing.Start(ctx, "idempotencyService.Begin")
+	defer tracing.End(span, &err)
+
+	// The second attempt follows the removal of an expired record or lease
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		record = model.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash,
+			ExpiresAt:   now.Add(s.lease),
+		}
+		created, err := s.keys.Create(ctx, &record)
+		if err != nil {
+			return record, false, NewError(ErrCodeDatabase, err)
+		}
+		if created {
+			return record, false, nil
+		}
+
+		if err = s.keys.FindByKey(ctx, &record, userID, key); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				// Removed since the insert, claim it again
+				continue
+			}
+			return record, false, NewError(ErrCodeDatabase, err)
+		}
+
+		if record.ExpiresAt.Before(now) {
+			// The key is forgotten, or its request died. Should that request finish
+			// after all, its Complete finds no record to update.
+			if err = s.keys.Delete(ctx, record.ID); err != nil {
+				return record, false, NewError(ErrCodeDatabase, err)
+			}
+			continue
+		}
+		if record.RequestHash != requestHash {
+			return record, false, NewError(ErrCodeIdempotencyKeyReused, nil)
+		}
+		if !record.Completed {
+			return record, false, NewError(ErrCodeIdempotencyKeyInProgress, nil)
+		}
+		return record, true, nil
+	}
+	return record, false, NewError(ErrCodeIdempotencyKeyInProgress, nil)
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, record *model.IdempotencyKey) (err error) {
+	ctx, span := tracing.Start(ctx, "idempotencyService.Complete")
+	defer tracing.End(span, &err)
+
+	record.Completed = true
+	record.ExpiresAt = time.Now().Add(s.ttl)
+	if err = s.keys.Save(ctx, record); err != nil {
+		return NewError(ErrCodeDatabase, err)
+	}
+	return
+}
+
+func (s *idempotencyService) Release(ctx context.Context, record *model.IdempotencyKey) (err error) {
+	ctx, span := tracing.Start(ctx, "idempotencyService.Release")
+	defer tracing.End(span, &err)
+
+	if err = s.keys.Delete(ctx, record.ID); err != nil {
+		return NewError(ErrCodeDatabase, err)
+	}
+	return
+}
+
+func (s *idempotencyService) PurgeExpired(ctx context.Context) (count int64, err error) {
+	ctx, span := tracing.Start(ctx, "idempotencyService.PurgeExpired")
+	defer tracing.End(span, &err)
+
+	return s.keys.DeleteExpired(ctx, time.Now())
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitlab.com/investio/backend/sim-api/config"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+)
+
+func TestIdempotencyLease(t *testing.T) {
+	const lease = 50 * time.Millisecond
+
+	backends := map[string]func(t *testing.T) repository.IdempotencyRepository{
+		"memory": func(t *testing.T) repository.IdempotencyRepository {
+			return repository.NewMemoryStore().Repositories().Idempotency
+		},
+		"sqlite": func(t *testing.T) repository.IdempotencyRepository {
+			return repository.NewIdempotencyRepository(newSQLiteDB(t))
+		},
+	}
+
+	for name, newKeys := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := NewIdempotencyService(newKeys(t), config.IdempotencyConfig{TTL: time.Hour, Lease: lease})
+
+			stale, _, err := svc.Begin(ctx, 7, "order-1", "hash")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := svc.Begin(ctx, 7, "order-1", "hash"); CodeOf(err) != ErrCodeIdempotencyKeyInProgress {
+				t.Fatalf("Begin within the lease: got %v, want %s", err, ErrCodeIdempotencyKeyInProgress)
+			}
+
+			// The first request never finishes, a retry after the lease runs again
+			time.Sleep(2 * lease)
+			record, replay, err := svc.Begin(ctx, 7, "order-1", "hash")
+			if err != nil || replay {
+				t.Fatalf("Begin after the lease: replay %v, %v; want to run the request", replay, err)
+			}
+			record.ResponseStatus = 200
+			if err := svc.Complete(ctx, &record); err != nil {
+				t.Fatal(err)
+			}
+
+			// The late first request does not overwrite the outcome of the retry
+			stale.ResponseStatus = 500
+			if err := svc.Complete(ctx, &stale); err == nil {
+				t.Error("Complete of a request that lost its lease succeeded")
+			}
+
+			// The outcome is kept for the TTL, not just the lease
+			time.Sleep(2 * lease)
+			replayed, replay, err := svc.Begin(ctx, 7, "order-1", "hash")
+			if err != nil || !replay || replayed.ResponseStatus != 200 {
+				t.Errorf("Begin after Complete = status %d, replay %v, %v; want the stored 200", replayed.ResponseStatus, replay, err)
+			}
+		})
+	}
+}
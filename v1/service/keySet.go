@@ -0,0 +1,199 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/investio/backend/sim-api/config"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// Unknown key IDs trigger a refresh, but not more often than this
+const minKeyRefreshInterval = time.Minute
+
+// KeySet provides the public keys that verify access tokens
+type KeySet interface {
+	// Key returns the verification key for a key ID from the JWT header.
+	// An empty kid is accepted when the set holds exactly one key.
+	Key(kid string) (key interface{}, err error)
+}
+
+type jwksKeySet struct {
+	fetch   func() ([]byte, error)
+	refresh time.Duration
+
+	// reloading lets one fetch run at a time
+	reloading sync.Mutex
+
+	mu          sync.RWMutex
+	keys        []jose.JSONWebKey
+	refreshedAt time.Time
+}
+
+// NewKeySet loads the keys from JWKSURL, or from the local JWKSFile when no endpoint is set
+func NewKeySet(cfg config.AuthConfig) (KeySet, error) {
+	if cfg.JWKSURL != "" {
+		return NewRemoteKeySet(cfg.JWKSURL, cfg.JWKSRefresh)
+	}
+	return NewFileKeySet(cfg.JWKSFile, cfg.JWKSRefresh)
+}
+
+// NewFileKeySet loads a JWKS document from a local file and re-reads it every refresh interval
+func NewFileKeySet(path string, refresh time.Duration) (KeySet, error) {
+	return newJWKSKeySet(func() ([]byte, error) {
+		return ioutil.ReadFile(path)
+	}, refresh)
+}
+
+// NewRemoteKeySet fetches a JWKS document from an endpoint and re-fetches it every refresh interval
+func NewRemoteKeySet(url string, refresh time.Duration) (KeySet, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return newJWKSKeySet(func() ([]byte, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("jwks endpoint responded %d", resp.StatusCode)
+		}
+		return ioutil.ReadAll(resp.Body)
+	}, refresh)
+}
+
+func newJWKSKeySet(fetch func() ([]byte, error), refresh time.Duration) (KeySet, error) {
+	ks := &jwksKeySet{
+		fetch:   fetch,
+		refresh: refresh,
+	}
+	if err := ks.load(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+func (ks *jwksKeySet) Key(kid string) (key interface{}, err error) {
+	// Tokens keep being checked against the cached keys while they are refreshed
+	if ks.stale(ks.refresh) {
+		ks.reloadInBackground(ks.refresh)
+	}
+
+	if key, ok := ks.lookup(kid); ok {
+		return key, nil
+	}
+
+	// The issuer may have rotated to a key we have not seen yet
+	if kid != "" && ks.stale(minKeyRefreshInterval) {
+		ks.reload(minKeyRefreshInterval)
+		if key, ok := ks.lookup(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no verification key for kid %q", kid)
+}
+
+func (ks *jwksKeySet) lookup(kid string) (interface{}, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if kid == "" {
+		if len(ks.keys) == 1 {
+			return ks.keys[0].Key, true
+		}
+		return nil, false
+	}
+
+	for _, k := range ks.keys {
+		if k.KeyID == kid {
+			return k.Key, true
+		}
+	}
+	return nil, false
+}
+
+func (ks *jwksKeySet) stale(age time.Duration) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return age > 0 && time.Since(ks.refreshedAt) > age
+}
+
+// reload fetches the keys when they are older than age, unless another caller
+// did while this one waited. It keeps serving the previous keys when the source
+// is unavailable.
+func (ks *jwksKeySet) reload(age time.Duration) {
+	ks.reloading.Lock()
+	defer ks.reloading.Unlock()
+
+	ks.reloadLocked(age)
+}
+
+// reloadInBackground starts a reload unless one is already running
+func (ks *jwksKeySet) reloadInBackground(age time.Duration) {
+	if !ks.reloading.TryLock() {
+		return
+	}
+	go func() {
+		defer ks.reloading.Unlock()
+		ks.reloadLocked(age)
+	}()
+}
+
+func (ks *jwksKeySet) reloadLocked(age time.Duration) {
+	if !ks.stale(age) {
+		return
+	}
+	if err := ks.load(); err != nil {
+		log.Warn("Refresh JWKS failed: ", err)
+	}
+}
+
+func (ks *jwksKeySet) load() (err error) {
+	defer func() {
+		// Failed attempts also count, so an unavailable source is not hammered
+		ks.mu.Lock()
+		ks.refreshedAt = time.Now()
+		ks.mu.Unlock()
+	}()
+
+	data, err := ks.fetch()
+	if err != nil {
+		return
+	}
+
+	var set jose.JSONWebKeySet
+	if err = json.Unmarshal(data, &set); err != nil {
+		return
+	}
+
+	keys := make([]jose.JSONWebKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		if !k.IsPublic() {
+			// A signing secret in a published document is a leak, so the key is not trusted
+			log.Warnf("JWKS key %q is a private key, ignoring it", k.KeyID)
+			continue
+		}
+		if !k.Valid() {
+			continue
+		}
+		keys = append(keys, k)
+	}
+
+	if len(keys) == 0 {
+		return errors.New("jwks has no usable verification keys")
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// newKey returns a signing key with the key ID
+func newKey(t *testing.T, kid string) jose.JSONWebKey {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jose.JSONWebKey{Key: private, KeyID: kid, Algorithm: string(jose.EdDSA), Use: "sig"}
+}
+
+func public(keys ...jose.JSONWebKey) []jose.JSONWebKey {
+	result := make([]jose.JSONWebKey, len(keys))
+	for i, k := range keys {
+		result[i] = k.Public()
+	}
+	return result
+}
+
+// writeJWKS writes the keys as a JWKS document to path
+func writeJWKS(t *testing.T, path string, keys ...jose.JSONWebKey) {
+	t.Helper()
+
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expire makes the keys look older than the minimum refresh interval
+func expire(ks KeySet) {
+	jwks := ks.(*jwksKeySet)
+	jwks.mu.Lock()
+	jwks.refreshedAt = time.Now().Add(-2 * minKeyRefreshInterval)
+	jwks.mu.Unlock()
+}
+
+func TestFileKeySet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	first, second := newKey(t, "first"), newKey(t, "second")
+	enc := newKey(t, "enc")
+	enc.Use = "enc"
+	writeJWKS(t, path, append(public(first, second, enc), newKey(t, "private"))...)
+
+	ks, err := NewFileKeySet(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		kid     string
+		want    jose.JSONWebKey
+		wantErr bool
+	}{
+		{kid: "first", want: first.Public()},
+		{kid: "second", want: second.Public()},
+		{kid: "unknown", wantErr: true},
+		// An empty kid is ambiguous with two keys
+		{kid: "", wantErr: true},
+		{kid: "enc", wantErr: true},
+		{kid: "private", wantErr: true},
+	} {
+		key, err := ks.Key(tc.kid)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Key(%q) = %v, want an error", tc.kid, key)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Key(%q): %v", tc.kid, err)
+			continue
+		}
+		if got, ok := key.(ed25519.PublicKey); !ok || !got.Equal(tc.want.Key) {
+			t.Errorf("Key(%q) = %T, want the public key of %q", tc.kid, key, tc.want.KeyID)
+		}
+	}
+
+	// A single key is used for tokens without a kid
+	writeJWKS(t, path, public(first)...)
+	single, err := NewFileKeySet(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := single.Key(""); err != nil || !key.(ed25519.PublicKey).Equal(first.Public().Key) {
+		t.Errorf("Key(\"\") of a single key = %v, %v", key, err)
+	}
+}
+
+func TestFileKeySetRejectsUnusableDocuments(t *testing.T) {
+	dir := t.TempDir()
+	enc := newKey(t, "enc")
+	enc.Use = "enc"
+
+	for name, write := range map[string]func(path string){
+		"missing":      func(path string) {},
+		"not JSON":     func(path string) { _ = os.WriteFile(path, []byte("keys"), 0o600) },
+		"no keys":      func(path string) { writeJWKS(t, path) },
+		"private only": func(path string) { writeJWKS(t, path, newKey(t, "private")) },
+		"enc only":     func(path string) { writeJWKS(t, path, enc.Public()) },
+	} {
+		path := filepath.Join(dir, name+".json")
+		write(path)
+		if _, err := NewFileKeySet(path, 0); err == nil {
+			t.Errorf("%s: loaded a document without verification keys", name)
+		}
+	}
+}
+
+func TestFileKeySetRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	old, rotated := newKey(t, "old"), newKey(t, "rotated")
+	writeJWKS(t, path, public(old)...)
+
+	ks, err := NewFileKeySet(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJWKS(t, path, public(rotated)...)
+
+	// Right after loading, an unknown kid does not re-read the file
+	if _, err := ks.Key("rotated"); err == nil {
+		t.Error("new key found before the minimum refresh interval")
+	}
+	expire(ks)
+	if _, err := ks.Key("rotated"); err != nil {
+		t.Errorf("rotated key: %v", err)
+	}
+	if _, err := ks.Key("old"); err == nil {
+		t.Error("key removed from the document still accepted")
+	}
+
+	// A source that fails keeps the keys loaded last
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	expire(ks)
+	if _, err := ks.Key("rotated"); err != nil {
+		t.Errorf("key after the source failed: %v", err)
+	}
+}
+
+func TestKeySetReloadsOnce(t *testing.T) {
+	key := newKey(t, "rotated")
+	var fetches int32
+	ks, err := newJWKSKeySet(func() ([]byte, error) {
+		keys := public(newKey(t, "old"))
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			// Slow enough for every caller to find the keys stale
+			time.Sleep(50 * time.Millisecond)
+			keys = public(key)
+		}
+		return json.Marshal(jose.JSONWebKeySet{Keys: keys})
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expire(ks)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ks.Key("rotated"); err != nil {
+				t.Errorf("rotated key: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&fetches); got != 2 {
+		t.Errorf("fetched the JWKS %d times, want once at start and once for the new kid", got)
+	}
+}
+
+func TestKeySetRefreshesInBackground(t *testing.T) {
+	old, rotated := newKey(t, "old"), newKey(t, "rotated")
+	var fetches int32
+	release := make(chan struct{})
+	ks, err := newJWKSKeySet(func() ([]byte, error) {
+		keys := public(old)
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			// A slow source must not hold up tokens signed with a cached key
+			<-release
+			keys = public(old, rotated)
+		}
+		return json.Marshal(jose.JSONWebKeySet{Keys: keys})
+	}, minKeyRefreshInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expire(ks)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if _, err := ks.Key("old"); err != nil {
+				t.Errorf("cached key while refreshing: %v", err)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("token checks waited for the refresh")
+	}
+
+	close(release)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, ok := ks.(*jwksKeySet).lookup("rotated"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("keys were not refreshed")
+		}
+	}
+	if got := atomic.LoadInt32(&fetches); got != 2 {
+		t.Errorf("fetched the JWKS %d times, want once at start and once in the background", got)
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/events"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/metrics"
+	"gitlab.com/investio/backend/sim-api/tracing"
+	"gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+)
+
+// OrderService places buy and sell orders. An order moves the wallet balance, then
+// the holding, then writes the transaction; when a later step fails the earlier
+// ones are reversed.
+type OrderService interface {
+	Buy(ctx context.Context, userID uint, req dto.OrderRequest) (tran model.Transaction, err error)
+	Sell(ctx context.Context, userID uint, req dto.OrderRequest) (tran model.Transaction, err error)
+}
+
+// Order status in an order event
+const (
+	OrderCompleted = "completed"
+	OrderFailed    = "failed"
+)
+
+// OrderUpdate is published when an order that passed validation completes or fails
+type OrderUpdate struct {
+	Status string
+	// Code of the error that failed the order
+	Code ErrorCode
+	// Order is not written when the order failed, so its ID and creation time are zero
+	Order model.Transaction
+}
+
+type orderService struct {
+	portService        PortService
+	walletService      WalletService
+	transactionService TransactionService
+	fundService        FundService
+	events             events.Publisher
+}
+
+func NewOrderService(port PortService, wallet WalletService, transaction TransactionService, fund FundService, publisher events.Publisher) OrderService {
+	return &orderService{
+		portService:        port,
+		walletService:      wallet,
+		transactionService: transaction,
+		fundService:        fund,
+		events:             publisher,
+	}
+}
+
+// validate checks the order and fills in the catalogue fund ID and category
+func (s *orderService) validate(ctx context.Context, userID uint, req *dto.OrderRequest) (err error) {
+	var port model.Port
+
+	if req.Amount.LessThanOrEqual(decimal.NewFromInt32(0)) {
+		return NewError(ErrCodeInvalidAmount, nil)
+	}
+
+	if req.Unit.LessThanOrEqual(decimal.NewFromInt32(0)) {
+		return NewError(ErrCodeInvalidUnit, nil)
+	}
+
+	if err = s.fundService.ValidateOrder(ctx, req); err != nil {
+		return
+	}
+
+	if err = s.portService.GetPort(ctx, &port, userID); err != nil {
+		return
+	}
+
+	if port.ID != req.PortID {
+		return NewError(ErrCodePortMismatch, nil)
+	}
+	return
+}
+
+func (s *orderService) Buy(ctx context.Context, userID uint, req dto.OrderRequest) (tran model.Transaction, err error) {
+	// A client that disconnects must not cancel the order half-way,
+	// nor the reversals that undo it
+	ctx = context.WithoutCancel(ctx)
+	ctx, span := tracing.Start(ctx, "orderService.Buy")
+	defer tracing.End(span, &err)
+
+	if err = s.validate(ctx, userID, &req); err != nil {
+		return
+	}
+	defer func() { s.publish(userID, model.TransactionBuy, req, tran, err) }()
+
+	if err = s.walletService.Purchase(ctx, req.Amount, userID); err != nil {
+		return tran, AsError(err, ErrCodeDatabase)
+	}
+
+	if err = s.portService.AddOrUpdateFund(ctx, req); err != nil {
+		if err := s.walletService.ReversePurchase(ctx, req.Amount, userID); err != nil {
+			logging.FromContext(ctx).Error("Critial [AddOrUpdateFund] - <rev> wallet purchase failed ", err.Error())
+			metrics.ReversalFailures.WithLabelValues(metrics.SideBuy, metrics.StepWallet).Inc()
+			return tran, NewError(ErrCodeReversalFailed, err)
+		}
+		return tran, AsError(err, ErrCodeDatabase)
+	}
+
+	tran = newTransaction(userID, model.TransactionBuy, req)
+	if err = s.transactionService.Write(ctx, &tran); err != nil {
+		if err := s.walletService.ReversePurchase(ctx, req.Amount, userID); err != nil {
+			logging.FromContext(ctx).Error("Critial [AddOrUpdateFund] - <rev> wallet purchase failed ", err.Error())
+			metrics.ReversalFailures.WithLabelValues(metrics.SideBuy, metrics.StepWallet).Inc()
+			return tran, NewError(ErrCodeReversalFailed, err)
+		}
+
+		// Reverse purchase fund
+		if err := s.portService.RedeemFund(ctx, req); err != nil {
+			logging.FromContext(ctx).Error("Critial [AddOrUpdateFund] - <rev> port purchase fund failed ", err.Error())
+			metrics.ReversalFailures.WithLabelValues(metrics.SideBuy, metrics.StepPort).Inc()
+			return tran, NewError(ErrCodeReversalFailed, err)
+		}
+		return
+	}
+	return
+}
+
+func (s *orderService) Sell(ctx context.Context, userID uint, req dto.OrderRequest) (tran model.Transaction, err error) {
+	// A client that disconnects must not cancel the order half-way,
+	// nor the reversals that undo it
+	ctx = context.WithoutCancel(ctx)
+	ctx, span := tracing.Start(ctx, "orderService.Sell")
+	defer tracing.End(span, &err)
+
+	if err = s.validate(ctx, userID, &req); err != nil {
+		return
+	}
+	defer func() { s.publish(userID, model.TransactionSell, req, tran, err) }()
+
+	if err = s.walletService.Redeem(ctx, req.Amount, userID); err != nil {
+		return tran, AsError(err, ErrCodeDatabase)
+	}
+
+	if err = s.portService.RedeemFund(ctx, req); err != nil {
+		if err := s.walletService.ReverseRedeem(ctx, req.Amount, userID); err != nil {
+			logging.FromContext(ctx).Error("Critial [RedeemFund] - <rev> wallet redeem failed ", err.Error())
+			metrics.ReversalFailures.WithLabelValues(metrics.SideSell, metrics.StepWallet).Inc()
+			return tran, NewError(ErrCodeReversalFailed, err)
+		}
+		return tran, AsError(err, ErrCodeDatabase)
+	}
+
+	tran = newTransaction(userID, model.TransactionSell, req)
+	if err = s.transactionService.Write(ctx, &tran); err != nil {
+		if err := s.walletService.ReverseRedeem(ctx, req.Amount, userID); err != nil {
+			logging.FromContext(ctx).Error("Critial [RedeemFund] - <rev> wallet redeem failed ", err.Error())
+			metrics.ReversalFailures.WithLabelValues(metrics.SideSell, metrics.StepWallet).Inc()
+			return tran, NewError(ErrCodeReversalFailed, err)
+		}
+		// Reverse redeem fund
+		if err := s.portService.AddOrUpdateFund(ctx, req); err != nil {
+			logging.FromContext(ctx).Error("Critial [RedeemFund] - <rev> port add/update failed ", err.Error())
+			metrics.ReversalFailures.WithLabelValues(metrics.SideSell, metrics.StepPort).Inc()
+			return tran, NewError(ErrCodeReversalFailed, err)
+		}
+		return
+	}
+	return
+}
+
+func (s *orderService) publish(userID uint, txType uint32, req dto.OrderRequest, tran model.Transaction, err error) {
+	update := OrderUpdate{Status: OrderCompleted, Order: tran}
+	if err != nil {
+		update = OrderUpdate{Status: OrderFailed, Code: CodeOf(err), Order: newTransaction(userID, txType, req)}
+	}
+	s.events.Publish(events.Event{Type: events.TypeOrder, UserID: userID, Data: update})
+}
+
+func newTransaction(userID uint, txType uint32, req dto.OrderRequest) model.Transaction {
+	return model.Transaction{
+		DataDate: req.DataDate.ParseTime(),
+		PortID:   req.PortID,
+		FundID:   req.FundID,
+		FundCode: req.FundCode,
+		BcatID:   req.BcatID,
+		Type:     txType,
+		UserID:   userID,
+		NAV:      req.NAV,
+		Amount:   req.Amount,
+		Unit:     req.Unit,
+	}
+}
@@ -1,122 +1,146 @@
 package service
 
 import (
+	"context"
 	"errors"
 
 	"github.com/shopspring/decimal"
-	"gitlab.com/investio/backend/sim-api/db"
+	"gitlab.com/investio/backend/sim-api/tracing"
 	"gitlab.com/investio/backend/sim-api/v1/dto"
 	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
 )
 
 type PortService interface {
-	CreatePort(userID uint) (port model.Port, err error)
-	GetPort(p *model.Port, userID uint) (err error)
-	GetFunds(funds *[]model.PortFund, portID uint) (err error)
-	AddOrUpdateFund(req dto.OrderRequest) (err error)
-	RedeemFund(req dto.OrderRequest) (err error)
+	CreatePort(ctx context.Context, userID uint) (port model.Port, err error)
+	GetPort(ctx context.Context, p *model.Port, userID uint) (err error)
+	GetFunds(ctx context.Context, funds *[]model.PortFund, portID uint) (err error)
+	AddOrUpdateFund(ctx context.Context, req dto.OrderRequest) (err error)
+	RedeemFund(ctx context.Context, req dto.OrderRequest) (err error)
 }
 
 type portService struct {
+	ports     repository.PortRepository
+	portFunds repository.PortFundRepository
 }
 
-func NewPortService() PortService {
-	return &portService{}
+func NewPortService(ports repository.PortRepository, portFunds repository.PortFundRepository) PortService {
+	return &portService{
+		ports:     ports,
+		portFunds: portFunds,
+	}
 }
 
-func (s *portService) CreatePort(userID uint) (port model.Port, err error) {
+func (s *portService) CreatePort(ctx context.Context, userID uint) (port model.Port, err error) {
+	ctx, span := tracing.Start(ctx, "portService.CreatePort")
+	defer tracing.End(span, &err)
+
 	port = model.Port{
 		PortName:           "My first port",
 		UserID:             userID,
 		ProfitLossRealized: decimal.NewFromInt(0),
 		AllCost:            decimal.NewFromInt(0),
 	}
-	err = db.SimDB.Create(&port).Error
+	err = s.ports.Create(ctx, &port)
 	return
 }
 
-func (s *portService) GetPort(p *model.Port, userID uint) (err error) {
-	err = db.SimDB.Where("user_id = ?", userID).First(p).Error
-	return
+func (s *portService) GetPort(ctx context.Context, p *model.Port, userID uint) (err error) {
+	ctx, span := tracing.Start(ctx, "portService.GetPort")
+	defer tracing.End(span, &err)
 
+	if err = s.ports.FindByUser(ctx, p, userID); err != nil {
+		return notFoundOr(err, ErrCodePortNotFound)
+	}
+	return
 }
 
-func (s *portService) GetFunds(funds *[]model.PortFund, portID uint) (err error) {
-	err = db.SimDB.Where("port_id = ?", portID).Find(funds).Error
+func (s *portService) GetFunds(ctx context.Context, funds *[]model.PortFund, portID uint) (err error) {
+	ctx, span := tracing.Start(ctx, "portService.GetFunds")
+	defer tracing.End(span, &err)
+
+	err = s.portFunds.FindByPort(ctx, funds, portID)
 	return
 }
 
-func (s *portService) AddOrUpdateFund(req dto.OrderRequest) (err error) {
-	var (
-		fund model.PortFund
-		port model.Port
-	)
-	if err = db.SimDB.Where("ID = ?", req.PortID).First(&port).Error; err != nil {
-		if (model.Port{}) == port {
-			return errors.New("port not found")
-		}
-		return
-	}
+func (s *portService) AddOrUpdateFund(ctx context.Context, req dto.OrderRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "portService.AddOrUpdateFund")
+	defer tracing.End(span, &err)
 
-	port.AllCost = port.AllCost.Add(req.Amount)
-	if err = db.SimDB.Save(&port).Error; err != nil {
+	if err = s.addCost(ctx, req.PortID, req.Amount); err != nil {
 		return
 	}
 
-	if err = db.SimDB.Where("fund_code = ?", req.FundCode).Where("port_id = ?", req.PortID).First(&fund).Error; err != nil {
-		// Create
-		fund := model.PortFund{
-			FundID:   req.FundID,
-			FundCode: req.FundCode,
-			BcatID:   req.BcatID,
-			PortID:   req.PortID,
-			Cost:     req.Amount,
-			Unit:     req.Unit,
+	err = retryOnConflict(ctx, func() error {
+		var fund model.PortFund
+
+		err := s.portFunds.FindByCode(ctx, &fund, req.PortID, req.FundCode)
+		if errors.Is(err, repository.ErrNotFound) {
+			// Create; when a concurrent first buy created it already, the retry updates it
+			fund := model.PortFund{
+				FundID:   req.FundID,
+				FundCode: req.FundCode,
+				BcatID:   req.BcatID,
+				PortID:   req.PortID,
+				Cost:     req.Amount,
+				Unit:     req.Unit,
+			}
+			return s.portFunds.Create(ctx, &fund)
+		}
+		if err != nil {
+			return NewError(ErrCodeDatabase, err)
 		}
-		err = db.SimDB.Create(&fund).Error
-		return
-	}
 
-	// Update
-	fund.Cost = fund.Cost.Add(req.Amount)
-	fund.Unit = fund.Unit.Add(req.Unit)
-	fund.BcatID = req.BcatID
-	err = db.SimDB.Save(&fund).Error
+		// Update
+		fund.Cost = fund.Cost.Add(req.Amount)
+		fund.Unit = fund.Unit.Add(req.Unit)
+		fund.BcatID = req.BcatID
+		return s.portFunds.Save(ctx, &fund)
+	})
 	return
 }
 
-func (s *portService) RedeemFund(req dto.OrderRequest) (err error) {
-	var (
-		fund model.PortFund
-		port model.Port
-	)
-	if err = db.SimDB.First(&port, req.PortID).Error; err != nil {
-		if (model.Port{}) == port {
-			return errors.New("port not found")
-		}
-		return
-	}
+func (s *portService) RedeemFund(ctx context.Context, req dto.OrderRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "portService.RedeemFund")
+	defer tracing.End(span, &err)
 
-	port.AllCost = port.AllCost.Add(req.Amount)
-	if err = db.SimDB.Save(&port).Error; err != nil {
+	if err = s.addCost(ctx, req.PortID, req.Amount); err != nil {
 		return
 	}
 
-	if err = db.SimDB.Where("fund_code = ?", req.FundCode).Where("port_id = ?", req.PortID).First(&fund).Error; err != nil {
-		return
-	}
+	err = retryOnConflict(ctx, func() error {
+		var fund model.PortFund
 
-	if fund.Cost.Sub(req.Amount).LessThan(decimal.NewFromInt(0)) {
-		return errors.New("reject: amount will be less than 0")
-	}
+		if err := s.portFunds.FindByCode(ctx, &fund, req.PortID, req.FundCode); err != nil {
+			return notFoundOr(err, ErrCodeHoldingNotFound)
+		}
 
-	if fund.Unit.Sub(req.Unit).LessThan(decimal.NewFromInt(0)) {
-		return errors.New("reject: unit will be less than 0")
-	}
+		if fund.Cost.Sub(req.Amount).LessThan(decimal.NewFromInt(0)) {
+			return NewError(ErrCodeInsufficientCost, nil)
+		}
 
-	fund.Cost = fund.Cost.Sub(req.Amount)
-	fund.Unit = fund.Unit.Sub(req.Unit)
-	fund.BcatID = req.BcatID
-	err = db.SimDB.Save(&fund).Error
+		if fund.Unit.Sub(req.Unit).LessThan(decimal.NewFromInt(0)) {
+			return NewError(ErrCodeInsufficientUnits, nil)
+		}
+
+		fund.Cost = fund.Cost.Sub(req.Amount)
+		fund.Unit = fund.Unit.Sub(req.Unit)
+		fund.BcatID = req.BcatID
+		return s.portFunds.Save(ctx, &fund)
+	})
 	return
 }
+
+// addCost adds amount to the cost of the port, reading it again when another order changed it first
+func (s *portService) addCost(ctx context.Context, portID uint, amount decimal.Decimal) error {
+	return retryOnConflict(ctx, func() error {
+		var port model.Port
+
+		if err := s.ports.FindByID(ctx, &port, portID); err != nil {
+			return notFoundOr(err, ErrCodePortNotFound)
+		}
+
+		port.AllCost = port.AllCost.Add(amount)
+		return s.ports.Save(ctx, &port)
+	})
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+)
+
+// portBackends builds the repositories of the port service on each storage
+var portBackends = map[string]func(t *testing.T) repository.Repositories{
+	"memory": func(t *testing.T) repository.Repositories {
+		return repository.NewMemoryStore().Repositories()
+	},
+	"sqlite": func(t *testing.T) repository.Repositories {
+		return repository.NewGormRepositories(newSQLiteDB(t))
+	},
+}
+
+// racingPorts runs race once, right after the first port is read,
+// as another order would between reading and saving it
+type racingPorts struct {
+	repository.PortRepository
+	race func()
+}
+
+func (r *racingPorts) FindByID(ctx context.Context, port *model.Port, id uint) error {
+	err := r.PortRepository.FindByID(ctx, port, id)
+	if race := r.race; race != nil {
+		r.race = nil
+		race()
+	}
+	return err
+}
+
+// racingPortFunds runs race once, right after the first holding is looked up
+type racingPortFunds struct {
+	repository.PortFundRepository
+	race func()
+}
+
+func (r *racingPortFunds) FindByCode(ctx context.Context, fund *model.PortFund, portID uint, fundCode string) error {
+	err := r.PortFundRepository.FindByCode(ctx, fund, portID, fundCode)
+	if race := r.race; race != nil {
+		r.race = nil
+		race()
+	}
+	return err
+}
+
+// brokenPortFunds fails every lookup of a holding
+type brokenPortFunds struct {
+	repository.PortFundRepository
+}
+
+func (r brokenPortFunds) FindByCode(ctx context.Context, fund *model.PortFund, portID uint, fundCode string) error {
+	return errors.New("connection lost")
+}
+
+func buyRequest(portID uint, amount, unit int64) dto.OrderRequest {
+	return dto.OrderRequest{
+		PortID:   portID,
+		FundID:   "M0001_2563",
+		FundCode: "SIM-SET50",
+		BcatID:   1,
+		Amount:   decimal.NewFromInt(amount),
+		Unit:     decimal.NewFromInt(unit),
+	}
+}
+
+func TestInterleavedOrdersKeepPortCost(t *testing.T) {
+	for name, newRepos := range portBackends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := newRepos(t)
+			ports := &racingPorts{PortRepository: repos.Ports}
+			svc := NewPortService(ports, repos.PortFunds)
+
+			port, err := svc.CreatePort(ctx, 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ports.race = func() {
+				if err := svc.AddOrUpdateFund(ctx, buyRequest(port.ID, 50, 5)); err != nil {
+					t.Errorf("racing buy: %v", err)
+				}
+			}
+			if err := svc.AddOrUpdateFund(ctx, buyRequest(port.ID, 100, 10)); err != nil {
+				t.Fatal(err)
+			}
+			if err := svc.RedeemFund(ctx, buyRequest(port.ID, 30, 3)); err != nil {
+				t.Fatal(err)
+			}
+
+			var stored model.Port
+			if err := repos.Ports.FindByID(ctx, &stored, port.ID); err != nil {
+				t.Fatal(err)
+			}
+			if want := decimal.NewFromInt(180); !stored.AllCost.Equal(want) {
+				t.Errorf("port cost = %s, want %s, the sum of the three order amounts", stored.AllCost, want)
+			}
+			if stored.Version != 3 {
+				t.Errorf("port version = %d, want one bump per order", stored.Version)
+			}
+		})
+	}
+}
+
+func TestConcurrentFirstBuysShareOneHolding(t *testing.T) {
+	for name, newRepos := range portBackends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := newRepos(t)
+			portFunds := &racingPortFunds{PortFundRepository: repos.PortFunds}
+			svc := NewPortService(repos.Ports, portFunds)
+
+			port, err := svc.CreatePort(ctx, 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Both buys find no holding before either creates one
+			portFunds.race = func() {
+				if err := svc.AddOrUpdateFund(ctx, buyRequest(port.ID, 50, 5)); err != nil {
+					t.Errorf("racing buy: %v", err)
+				}
+			}
+			if err := svc.AddOrUpdateFund(ctx, buyRequest(port.ID, 100, 10)); err != nil {
+				t.Fatal(err)
+			}
+
+			var holdings []model.PortFund
+			if err := svc.GetFunds(ctx, &holdings, port.ID); err != nil {
+				t.Fatal(err)
+			}
+			if len(holdings) != 1 {
+				t.Fatalf("port holds the fund %d times, want once", len(holdings))
+			}
+			if h := holdings[0]; !h.Cost.Equal(decimal.NewFromInt(150)) || !h.Unit.Equal(decimal.NewFromInt(15)) {
+				t.Errorf("holding = %s for %s units, want 150 for 15", h.Cost, h.Unit)
+			}
+		})
+	}
+}
+
+func TestFailedHoldingLookupCreatesNothing(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryStore().Repositories()
+	svc := NewPortService(repos.Ports, brokenPortFunds{repos.PortFunds})
+
+	port, err := svc.CreatePort(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddOrUpdateFund(ctx, buyRequest(port.ID, 100, 10)); CodeOf(err) != ErrCodeDatabase {
+		t.Fatalf("buy with a failing lookup: got %v, want %s", err, ErrCodeDatabase)
+	}
+
+	var holdings []model.PortFund
+	if err := repos.PortFunds.FindByPort(ctx, &holdings, port.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(holdings) != 0 {
+		t.Errorf("failed lookup created %d holdings", len(holdings))
+	}
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+)
+
+// maxUpdateAttempts bounds how often a read-modify-write is run again after losing a race
+const maxUpdateAttempts = 5
+
+// retryOnConflict runs update, which must read the records it changes, again for as long as
+// it fails with repository.ErrConflict. It gives up with ErrCodeConcurrentUpdate.
+func retryOnConflict(ctx context.Context, update func() error) (err error) {
+	for attempt := 1; ; attempt++ {
+		if err = update(); !errors.Is(err, repository.ErrConflict) {
+			return
+		}
+		if attempt == maxUpdateAttempts || ctx.Err() != nil {
+			return NewError(ErrCodeConcurrentUpdate, err)
+		}
+		// Back off a little, with jitter, so racing updates spread out
+		time.Sleep(time.Duration(rand.Int63n(int64(attempt) * int64(time.Millisecond))))
+	}
+}
@@ -1,35 +1,44 @@
 package service
 
 import (
-	"errors"
+	"context"
 
-	"gitlab.com/investio/backend/sim-api/db"
+	"gitlab.com/investio/backend/sim-api/tracing"
 	"gitlab.com/investio/backend/sim-api/v1/model"
-	"gorm.io/gorm"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
 )
 
 type TransactionService interface {
-	Get(transList *[]model.Transaction, userID uint) (err error)
-	Write(tran *model.Transaction) (err error)
+	Get(ctx context.Context, transList *[]model.Transaction, userID uint) (err error)
+	Write(ctx context.Context, tran *model.Transaction) (err error)
 }
 
 type transactionService struct {
+	transactions repository.TransactionRepository
 }
 
-func NewTransctionService() TransactionService {
-	return &transactionService{}
+func NewTransctionService(transactions repository.TransactionRepository) TransactionService {
+	return &transactionService{
+		transactions: transactions,
+	}
 }
 
-func (s *transactionService) Get(transList *[]model.Transaction, userID uint) (err error) {
-	if err = db.SimDB.Limit(50).Where("user_id = ?", userID).Order("data_date desc").Find(transList).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("not found")
-		}
+func (s *transactionService) Get(ctx context.Context, transList *[]model.Transaction, userID uint) (err error) {
+	ctx, span := tracing.Start(ctx, "transactionService.Get")
+	defer tracing.End(span, &err)
+
+	if err = s.transactions.FindByUser(ctx, transList, userID, 50); err != nil {
+		return NewError(ErrCodeDatabase, err)
 	}
 	return
 }
 
-func (s *transactionService) Write(tran *model.Transaction) (err error) {
-	err = db.SimDB.Create(&tran).Error
+func (s *transactionService) Write(ctx context.Context, tran *model.Transaction) (err error) {
+	ctx, span := tracing.Start(ctx, "transactionService.Write")
+	defer tracing.End(span, &err)
+
+	if err = s.transactions.Create(ctx, tran); err != nil {
+		return NewError(ErrCodeDatabase, err)
+	}
 	return
 }
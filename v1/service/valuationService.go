@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/events"
+	"gitlab.com/investio/backend/sim-api/logging"
+	"gitlab.com/investio/backend/sim-api/tracing"
+	"gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+)
+
+type ValuationService interface {
+	// Valuate prices the holdings of the user at the catalogue NAVs
+	Valuate(ctx context.Context, userID uint) (valuation dto.Valuation, err error)
+	// Watch publishes a new valuation to the subscribed users whose orders completed
+	// or whose funds changed NAV, until ctx is done or the bus is closed
+	Watch(ctx context.Context, bus events.Bus)
+}
+
+type valuationService struct {
+	portService PortService
+	funds       repository.FundRepository
+}
+
+func NewValuationService(port PortService, funds repository.FundRepository) ValuationService {
+	return &valuationService{
+		portService: port,
+		funds:       funds,
+	}
+}
+
+func (s *valuationService) Valuate(ctx context.Context, userID uint) (valuation dto.Valuation, err error) {
+	ctx, span := tracing.Start(ctx, "valuationService.Valuate")
+	defer tracing.End(span, &err)
+
+	var (
+		port     model.Port
+		holdings []model.PortFund
+		funds    []model.Fund
+	)
+
+	if err = s.portService.GetPort(ctx, &port, userID); err != nil {
+		return
+	}
+	if err = s.portService.GetFunds(ctx, &holdings, port.ID); err != nil {
+		return valuation, AsError(err, ErrCodeDatabase)
+	}
+
+	codes := make([]string, len(holdings))
+	for i, h := range holdings {
+		codes[i] = h.FundCode
+	}
+	if err = s.funds.FindByCodes(ctx, &funds, codes); err != nil {
+		return valuation, NewError(ErrCodeDatabase, err)
+	}
+	navs := make(map[string]decimal.NullDecimal, len(funds))
+	for _, f := range funds {
+		navs[f.Code] = f.NAV
+	}
+
+	valuation = dto.Valuation{
+		PortID:      port.ID,
+		Cost:        decimal.NewFromInt(0),
+		MarketValue: decimal.NewFromInt(0),
+		Funds:       make([]dto.HoldingValuation, 0, len(holdings)),
+	}
+	for _, h := range holdings {
+		holding := dto.HoldingValuation{
+			FundID:      h.FundID,
+			FundCode:    h.FundCode,
+			BcatID:      h.BcatID,
+			Cost:        h.Cost,
+			Unit:        h.Unit,
+			NAV:         navs[h.FundCode],
+			MarketValue: h.Cost,
+		}
+		if holding.NAV.Valid {
+			holding.MarketValue = h.Unit.Mul(holding.NAV.Decimal).Round(2)
+		}
+		holding.PlUnrealized = holding.MarketValue.Sub(h.Cost)
+
+		valuation.Cost = valuation.Cost.Add(h.Cost)
+		valuation.MarketValue = valuation.MarketValue.Add(holding.MarketValue)
+		valuation.Funds = append(valuation.Funds, holding)
+	}
+	valuation.PlUnrealized = valuation.MarketValue.Sub(valuation.Cost)
+	return
+}
+
+func (s *valuationService) Watch(ctx context.Context, bus events.Bus) {
+	sub := bus.Subscribe(0)
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			switch e.Type {
+			case events.TypeOrder:
+				if update, _ := e.Data.(OrderUpdate); update.Status == OrderCompleted && bus.Subscribed(e.UserID) {
+					s.publish(ctx, bus, e.UserID, nil)
+				}
+			case events.TypeNAV:
+				changed, _ := e.Data.([]model.Fund)
+				codes := make(map[string]bool, len(changed))
+				for _, f := range changed {
+					codes[f.Code] = true
+				}
+				for _, userID := range bus.Subscribers() {
+					s.publish(ctx, bus, userID, codes)
+				}
+			}
+		}
+	}
+}
+
+// publish sends the valuation of the user, when it holds one of codes or codes is nil
+func (s *valuationService) publish(ctx context.Context, bus events.Bus, userID uint, codes map[string]bool) {
+	valuation, err := s.Valuate(ctx, userID)
+	if err != nil {
+		if CodeOf(err) != ErrCodePortNotFound {
+			logging.FromContext(ctx).Warnf("Valuation of user %d failed: %v", userID, err)
+		}
+		return
+	}
+
+	if codes != nil {
+		held := false
+		for _, h := range valuation.Funds {
+			held = held || codes[h.FundCode]
+		}
+		if !held {
+			return
+		}
+	}
+	bus.Publish(events.Event{Type: events.TypeValuation, UserID: userID, Data: valuation})
+}
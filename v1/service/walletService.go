@@ -1,107 +1,166 @@
 package service
 
 import (
-	"errors"
+	"context"
 
 	"github.com/shopspring/decimal"
-	"gitlab.com/investio/backend/sim-api/db"
+	"gitlab.com/investio/backend/sim-api/config"
+	"gitlab.com/investio/backend/sim-api/events"
+	"gitlab.com/investio/backend/sim-api/tracing"
 	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
 )
 
 type WalletService interface {
-	GetWallet(wallet *model.Wallet, userID uint) (err error)
-	CreateWallet(userID uint) (wallet model.Wallet, err error)
-	Purchase(amount decimal.Decimal, userID uint) (err error)
-	Redeem(amount decimal.Decimal, userID uint) (err error)
-	ReversePurchase(amount decimal.Decimal, userID uint) (err error)
-	ReverseRedeem(amount decimal.Decimal, userID uint) (err error)
+	GetWallet(ctx context.Context, wallet *model.Wallet, userID uint) (err error)
+	CreateWallet(ctx context.Context, userID uint) (wallet model.Wallet, err error)
+	Purchase(ctx context.Context, amount decimal.Decimal, userID uint) (err error)
+	Redeem(ctx context.Context, amount decimal.Decimal, userID uint) (err error)
+	ReversePurchase(ctx context.Context, amount decimal.Decimal, userID uint) (err error)
+	ReverseRedeem(ctx context.Context, amount decimal.Decimal, userID uint) (err error)
 }
 
 type walletService struct {
+	wallets      repository.WalletRepository
 	startBalance decimal.Decimal
+	events       events.Publisher
 }
 
-func NewWalletService() WalletService {
+// NewWalletService publishes the wallet on publisher after every change to its balances
+func NewWalletService(wallets repository.WalletRepository, cfg config.WalletConfig, publisher events.Publisher) WalletService {
 	return &walletService{
-		startBalance: decimal.NewFromInt32(1000000),
+		wallets:      wallets,
+		startBalance: cfg.StartBalance,
+		events:       publisher,
 	}
 }
 
-func (s *walletService) CreateWallet(userID uint) (wallet model.Wallet, err error) {
+func (s *walletService) CreateWallet(ctx context.Context, userID uint) (wallet model.Wallet, err error) {
+	ctx, span := tracing.Start(ctx, "walletService.CreateWallet")
+	defer tracing.End(span, &err)
+
 	wallet = model.Wallet{
 		UserID:       userID,
-		AvaliableBal: s.startBalance,
+		AvailableBal: s.startBalance,
 		InOrderBal:   decimal.NewFromInt32(0),
 		InAssetBal:   decimal.NewFromInt32(0),
 		TotalSpend:   decimal.NewFromInt32(0),
 	}
-	err = db.SimDB.Create(&wallet).Error
+	if err = s.wallets.Create(ctx, &wallet); err != nil {
+		return
+	}
+	publishWallet(s.events, wallet)
 	return
 }
 
-func (s *walletService) GetWallet(wallet *model.Wallet, userID uint) (err error) {
-	err = db.SimDB.Where("user_id = ?", userID).First(&wallet).Error
+func (s *walletService) GetWallet(ctx context.Context, wallet *model.Wallet, userID uint) (err error) {
+	ctx, span := tracing.Start(ctx, "walletService.GetWallet")
+	defer tracing.End(span, &err)
+
+	if err = s.wallets.FindByUser(ctx, wallet, userID); err != nil {
+		return notFoundOr(err, ErrCodeWalletNotFound)
+	}
 	return
 }
 
-func (s *walletService) Purchase(amount decimal.Decimal, userID uint) (err error) {
-	var wallet model.Wallet
-
-	if err = db.SimDB.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return
-	}
+func (s *walletService) Purchase(ctx context.Context, amount decimal.Decimal, userID uint) (err error) {
+	ctx, span := tracing.Start(ctx, "walletService.Purchase")
+	defer tracing.End(span, &err)
 
-	if wallet.AvaliableBal.Sub(amount).LessThan(decimal.NewFromInt(0)) {
-		return errors.New("reject: avaliable balance will be less than zero")
+	var wallet model.Wallet
+	err = retryOnConflict(ctx, func() error {
+		if err := s.GetWallet(ctx, &wallet, userID); err != nil {
+			return err
+		}
+
+		if wallet.IsFrozen {
+			return NewError(ErrCodeAccountFrozen, nil)
+		}
+
+		if wallet.AvailableBal.Sub(amount).LessThan(decimal.NewFromInt(0)) {
+			return NewError(ErrCodeInsufficientBalance, nil)
+		}
+
+		wallet.AvailableBal = wallet.AvailableBal.Sub(amount)
+		wallet.InAssetBal = wallet.InAssetBal.Add(amount)
+		wallet.TotalSpend = wallet.TotalSpend.Add(amount)
+		return s.wallets.Save(ctx, &wallet)
+	})
+	if err == nil {
+		publishWallet(s.events, wallet)
 	}
-
-	wallet.AvaliableBal = wallet.AvaliableBal.Sub(amount)
-	wallet.InAssetBal = wallet.InAssetBal.Add(amount)
-	wallet.TotalSpend = wallet.TotalSpend.Add(amount)
-	err = db.SimDB.Save(&wallet).Error
 	return
 }
 
-func (s *walletService) ReversePurchase(amount decimal.Decimal, userID uint) (err error) {
-	var wallet model.Wallet
+func (s *walletService) ReversePurchase(ctx context.Context, amount decimal.Decimal, userID uint) (err error) {
+	ctx, span := tracing.Start(ctx, "walletService.ReversePurchase")
+	defer tracing.End(span, &err)
 
-	if err = db.SimDB.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return
+	var wallet model.Wallet
+	err = retryOnConflict(ctx, func() error {
+		if err := s.GetWallet(ctx, &wallet, userID); err != nil {
+			return err
+		}
+
+		wallet.AvailableBal = wallet.AvailableBal.Add(amount)
+		wallet.InAssetBal = wallet.InAssetBal.Sub(amount)
+		wallet.TotalSpend = wallet.TotalSpend.Sub(amount)
+		return s.wallets.Save(ctx, &wallet)
+	})
+	if err == nil {
+		publishWallet(s.events, wallet)
 	}
-
-	wallet.AvaliableBal = wallet.AvaliableBal.Add(amount)
-	wallet.InAssetBal = wallet.InAssetBal.Sub(amount)
-	wallet.TotalSpend = wallet.TotalSpend.Sub(amount)
-	err = db.SimDB.Save(&wallet).Error
 	return
 }
 
-func (s *walletService) Redeem(amount decimal.Decimal, userID uint) (err error) {
-	var wallet model.Wallet
+func (s *walletService) Redeem(ctx context.Context, amount decimal.Decimal, userID uint) (err error) {
+	ctx, span := tracing.Start(ctx, "walletService.Redeem")
+	defer tracing.End(span, &err)
 
-	if err = db.SimDB.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return
-	}
-
-	if wallet.InAssetBal.Sub(amount).LessThan(decimal.NewFromInt(0)) {
-		return errors.New("reject: in asset balance will be less than zero")
+	var wallet model.Wallet
+	err = retryOnConflict(ctx, func() error {
+		if err := s.GetWallet(ctx, &wallet, userID); err != nil {
+			return err
+		}
+
+		if wallet.IsFrozen {
+			return NewError(ErrCodeAccountFrozen, nil)
+		}
+
+		if wallet.InAssetBal.Sub(amount).LessThan(decimal.NewFromInt(0)) {
+			return NewError(ErrCodeInsufficientAsset, nil)
+		}
+
+		wallet.AvailableBal = wallet.AvailableBal.Add(amount)
+		wallet.InAssetBal = wallet.InAssetBal.Sub(amount)
+		return s.wallets.Save(ctx, &wallet)
+	})
+	if err == nil {
+		publishWallet(s.events, wallet)
 	}
-
-	wallet.AvaliableBal = wallet.AvaliableBal.Add(amount)
-	wallet.InAssetBal = wallet.InAssetBal.Sub(amount)
-	err = db.SimDB.Save(&wallet).Error
 	return
 }
 
-func (s *walletService) ReverseRedeem(amount decimal.Decimal, userID uint) (err error) {
-	var wallet model.Wallet
+func (s *walletService) ReverseRedeem(ctx context.Context, amount decimal.Decimal, userID uint) (err error) {
+	ctx, span := tracing.Start(ctx, "walletService.ReverseRedeem")
+	defer tracing.End(span, &err)
 
-	if err = db.SimDB.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return
+	var wallet model.Wallet
+	err = retryOnConflict(ctx, func() error {
+		if err := s.GetWallet(ctx, &wallet, userID); err != nil {
+			return err
+		}
+
+		wallet.AvailableBal = wallet.AvailableBal.Sub(amount)
+		wallet.InAssetBal = wallet.InAssetBal.Add(amount)
+		return s.wallets.Save(ctx, &wallet)
+	})
+	if err == nil {
+		publishWallet(s.events, wallet)
 	}
-
-	wallet.AvaliableBal = wallet.AvaliableBal.Sub(amount)
-	wallet.InAssetBal = wallet.InAssetBal.Add(amount)
-	err = db.SimDB.Save(&wallet).Error
 	return
 }
+
+func publishWallet(publisher events.Publisher, wallet model.Wallet) {
+	publisher.Publish(events.Event{Type: events.TypeWallet, UserID: wallet.UserID, Data: wallet})
+}
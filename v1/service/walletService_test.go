@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gitlab.com/investio/backend/sim-api/config"
+	"gitlab.com/investio/backend/sim-api/db"
+	"gitlab.com/investio/backend/sim-api/events"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newSQLiteDB opens a migrated SQLite database
+func newSQLiteDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "sim.db")
+	gormDB, err := gorm.Open(sqlite.Open("file:"+path+"?_pragma=busy_timeout(5000)"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := db.NewMigrator(gormDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return gormDB
+}
+
+// newSQLiteWallets returns a wallet repository on a migrated SQLite database
+func newSQLiteWallets(t *testing.T) repository.WalletRepository {
+	return repository.NewWalletRepository(newSQLiteDB(t))
+}
+
+// slowWallets pauses after every read, so concurrent updates of one wallet
+// reliably interleave between reading and saving it
+type slowWallets struct {
+	repository.WalletRepository
+}
+
+func (r slowWallets) FindByUser(ctx context.Context, wallet *model.Wallet, userID uint) error {
+	err := r.WalletRepository.FindByUser(ctx, wallet, userID)
+	time.Sleep(time.Millisecond)
+	return err
+}
+
+func TestConcurrentPurchasesNeverOverdraw(t *testing.T) {
+	const (
+		userID = 7
+		buyers = 40
+	)
+	var (
+		start  = decimal.NewFromInt(1000)
+		amount = decimal.NewFromInt(100)
+	)
+
+	backends := map[string]func(t *testing.T) repository.WalletRepository{
+		"memory": func(t *testing.T) repository.WalletRepository {
+			return repository.NewMemoryStore().Repositories().Wallets
+		},
+		"sqlite": newSQLiteWallets,
+	}
+
+	for name, newWallets := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			wallets := slowWallets{newWallets(t)}
+			bus := events.NewMemoryBus()
+			sub := bus.Subscribe(userID)
+			svc := NewWalletService(wallets, config.WalletConfig{StartBalance: start}, bus)
+			if _, err := svc.CreateWallet(ctx, userID); err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				wg        sync.WaitGroup
+				mu        sync.Mutex
+				purchased int
+			)
+			for i := 0; i < buyers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err := svc.Purchase(ctx, amount, userID)
+					switch code := CodeOf(err); {
+					case err == nil:
+						mu.Lock()
+						purchased++
+						mu.Unlock()
+					case code == ErrCodeInsufficientBalance, code == ErrCodeConcurrentUpdate:
+					default:
+						t.Errorf("purchase: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			var wallet model.Wallet
+			if err := svc.GetWallet(ctx, &wallet, userID); err != nil {
+				t.Fatal(err)
+			}
+			if wallet.AvailableBal.IsNegative() {
+				t.Fatalf("available balance went negative: %s", wallet.AvailableBal)
+			}
+			if purchased > 10 {
+				t.Fatalf("%d purchases of %s succeeded from a balance of %s", purchased, amount, start)
+			}
+
+			spent := amount.Mul(decimal.NewFromInt(int64(purchased)))
+			if want := start.Sub(spent); !wallet.AvailableBal.Equal(want) {
+				t.Errorf("available balance = %s, want %s after %d purchases", wallet.AvailableBal, want, purchased)
+			}
+			if !wallet.InAssetBal.Equal(spent) {
+				t.Errorf("in-asset balance = %s, want %s", wallet.InAssetBal, spent)
+			}
+			if wallet.Version != uint(purchased) {
+				t.Errorf("version = %d, want one bump per purchase (%d)", wallet.Version, purchased)
+			}
+
+			// One event for the new wallet, then one per saved purchase
+			bus.Close()
+			published := 0
+			for e := range sub.C {
+				if e.Type != events.TypeWallet || e.UserID != userID {
+					t.Errorf("unexpected event %s for user %d", e.Type, e.UserID)
+				}
+				published++
+			}
+			if published != purchased+1 {
+				t.Errorf("published %d wallet events, want %d", published, purchased+1)
+			}
+		})
+	}
+}
+
+func TestSaveStaleWalletConflicts(t *testing.T) {
+	ctx := context.Background()
+	wallets := newSQLiteWallets(t)
+
+	wallet := model.Wallet{UserID: 7, AvailableBal: decimal.NewFromInt(100)}
+	if err := wallets.Create(ctx, &wallet); err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second model.Wallet
+	if err := wallets.FindByUser(ctx, &first, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := wallets.FindByUser(ctx, &second, 7); err != nil {
+		t.Fatal(err)
+	}
+
+	first.AvailableBal = decimal.NewFromInt(40)
+	if err := wallets.Save(ctx, &first); err != nil {
+		t.Fatalf("first save: %v", err)
+	}
+	second.AvailableBal = decimal.NewFromInt(70)
+	if err := wallets.Save(ctx, &second); err != repository.ErrConflict {
+		t.Fatalf("stale save: got %v, want ErrConflict", err)
+	}
+	if second.Version != 0 {
+		t.Errorf("failed save changed the version to %d", second.Version)
+	}
+
+	var stored model.Wallet
+	if err := wallets.FindByUser(ctx, &stored, 7); err != nil {
+		t.Fatal(err)
+	}
+	if !stored.AvailableBal.Equal(decimal.NewFromInt(40)) || stored.Version != 1 {
+		t.Errorf("stored wallet = %s at version %d, want 40 at version 1", stored.AvailableBal, stored.Version)
+	}
+}
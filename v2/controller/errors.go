@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+)
+
+// abortWithError hands err to middleware.ErrorHandler, which writes the response
+func abortWithError(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	ctx.Abort()
+}
+
+// idParam parses the :id path parameter, aborting the request when it is not a valid ID
+func idParam(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		abortWithError(ctx, service.NewError(service.ErrCodeInvalidRequest, err))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/v1/middleware"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"gitlab.com/investio/backend/sim-api/v2/dto"
+)
+
+type OrderController interface {
+	ListOrders(ctx *gin.Context)
+	PlaceOrder(ctx *gin.Context)
+}
+
+type orderController struct {
+	orderService       service.OrderService
+	transactionService service.TransactionService
+}
+
+func NewOrderController(order service.OrderService, transaction service.TransactionService) OrderController {
+	return &orderController{
+		orderService:       order,
+		transactionService: transaction,
+	}
+}
+
+// ListOrders responds with the latest orders of the user, newest first
+func (c *orderController) ListOrders(ctx *gin.Context) {
+	var trans []model.Transaction
+
+	claims := middleware.Claims(ctx)
+
+	if err := c.transactionService.Get(ctx.Request.Context(), &trans, claims.UserID); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewOrders(trans))
+}
+
+// PlaceOrder buys or sells a fund and responds with the order
+func (c *orderController) PlaceOrder(ctx *gin.Context) {
+	var (
+		req  dto.PlaceOrderRequest
+		tran model.Transaction
+		err  error
+	)
+
+	claims := middleware.Claims(ctx)
+
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, service.NewError(service.ErrCodeInvalidRequest, err))
+		return
+	}
+	if !req.Side.Valid() {
+		abortWithError(ctx, service.NewError(service.ErrCodeInvalidRequest, nil))
+		return
+	}
+	middleware.SetOrderSide(ctx, string(req.Side))
+
+	if req.Side == dto.OrderBuy {
+		tran, err = c.orderService.Buy(ctx.Request.Context(), claims.UserID, req.OrderRequest())
+	} else {
+		tran, err = c.orderService.Sell(ctx.Request.Context(), claims.UserID, req.OrderRequest())
+	}
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dto.NewOrder(tran))
+}
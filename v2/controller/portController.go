@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/v1/middleware"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"gitlab.com/investio/backend/sim-api/v2/dto"
+)
+
+type PortController interface {
+	ListPorts(ctx *gin.Context)
+	GetPort(ctx *gin.Context)
+	ListHoldings(ctx *gin.Context)
+}
+
+type portController struct {
+	portService service.PortService
+}
+
+func NewPortController(port service.PortService) PortController {
+	return &portController{
+		portService: port,
+	}
+}
+
+// ListPorts responds with the ports of the user, opening the first one on first visit
+func (c *portController) ListPorts(ctx *gin.Context) {
+	var port model.Port
+
+	claims := middleware.Claims(ctx)
+
+	if err := c.portService.GetPort(ctx.Request.Context(), &port, claims.UserID); err != nil {
+		if service.CodeOf(err) != service.ErrCodePortNotFound {
+			abortWithError(ctx, err)
+			return
+		}
+		if port, err = c.portService.CreatePort(ctx.Request.Context(), claims.UserID); err != nil {
+			abortWithError(ctx, service.AsError(err, service.ErrCodeDatabase))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, []dto.Port{dto.NewPort(port)})
+}
+
+func (c *portController) GetPort(ctx *gin.Context) {
+	port, ok := c.ownPort(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewPort(port))
+}
+
+func (c *portController) ListHoldings(ctx *gin.Context) {
+	var funds []model.PortFund
+
+	port, ok := c.ownPort(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.portService.GetFunds(ctx.Request.Context(), &funds, port.ID); err != nil {
+		abortWithError(ctx, service.AsError(err, service.ErrCodeDatabase))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewHoldings(funds))
+}
+
+// ownPort loads the :id port, aborting the request when it is not a port of the user.
+// Ports of other users are reported as not found.
+func (c *portController) ownPort(ctx *gin.Context) (port model.Port, ok bool) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	claims := middleware.Claims(ctx)
+	if err := c.portService.GetPort(ctx.Request.Context(), &port, claims.UserID); err != nil {
+		abortWithError(ctx, err)
+		return port, false
+	}
+	if port.ID != id {
+		abortWithError(ctx, service.NewError(service.ErrCodePortNotFound, nil))
+		return port, false
+	}
+	return port, true
+}
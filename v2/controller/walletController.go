@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/investio/backend/sim-api/v1/middleware"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+	"gitlab.com/investio/backend/sim-api/v1/service"
+	"gitlab.com/investio/backend/sim-api/v2/dto"
+)
+
+type WalletController interface {
+	GetWallet(ctx *gin.Context)
+}
+
+type walletController struct {
+	walletService service.WalletService
+}
+
+func NewWalletController(wallet service.WalletService) WalletController {
+	return &walletController{
+		walletService: wallet,
+	}
+}
+
+// GetWallet responds with the wallet of the user, opening it on first visit
+func (c *walletController) GetWallet(ctx *gin.Context) {
+	var wallet model.Wallet
+
+	claims := middleware.Claims(ctx)
+
+	if err := c.walletService.GetWallet(ctx.Request.Context(), &wallet, claims.UserID); err != nil {
+		if service.CodeOf(err) != service.ErrCodeWalletNotFound {
+			abortWithError(ctx, err)
+			return
+		}
+		if wallet, err = c.walletService.CreateWallet(ctx.Request.Context(), claims.UserID); err != nil {
+			abortWithError(ctx, service.AsError(err, service.ErrCodeDatabase))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewWallet(wallet))
+}
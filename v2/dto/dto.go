@@ -0,0 +1,64 @@
+// Package dto holds the request and response bodies of /sim/v2. Unlike v1, which
+// responds with the stored models, every field is named here on purpose: decimals
+// are strings with a fixed scale and dates are ISO 8601.
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Decimal scales, the same as the database columns
+const (
+	moneyScale = 2
+	unitScale  = 8
+	navScale   = 4
+)
+
+func money(d decimal.Decimal) string {
+	return d.StringFixed(moneyScale)
+}
+
+func units(d decimal.Decimal) string {
+	return d.StringFixed(unitScale)
+}
+
+func nav(d decimal.Decimal) string {
+	return d.StringFixed(navScale)
+}
+
+const dateLayout = "2006-01-02"
+
+// Date is a calendar date, written as YYYY-MM-DD
+type Date struct {
+	time.Time
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(dateLayout))
+}
+
+// UnmarshalJSON accepts YYYY-MM-DD only
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("date must be a YYYY-MM-DD string")
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return fmt.Errorf("date must be a YYYY-MM-DD string")
+	}
+	d.Time = t
+	return nil
+}
+
+// dateOf returns nil for a zero time, which orders placed without a NAV date have
+func dateOf(t time.Time) *Date {
+	if t.IsZero() {
+		return nil
+	}
+	return &Date{t}
+}
@@ -0,0 +1,88 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	v1dto "gitlab.com/investio/backend/sim-api/v1/dto"
+	"gitlab.com/investio/backend/sim-api/v1/model"
+)
+
+type OrderSide string
+
+const (
+	OrderBuy  OrderSide = "buy"
+	OrderSell OrderSide = "sell"
+)
+
+func (s OrderSide) Valid() bool {
+	return s == OrderBuy || s == OrderSell
+}
+
+// PlaceOrderRequest buys or sells a fund. Decimals may be sent as strings or numbers;
+// strings keep their precision.
+type PlaceOrderRequest struct {
+	PortID   uint            `json:"port_id"`
+	FundCode string          `json:"fund_code"`
+	FundID   string          `json:"fund_id"`
+	Side     OrderSide       `json:"side"`
+	Amount   decimal.Decimal `json:"amount"`
+	Units    decimal.Decimal `json:"units"`
+	NAV      decimal.Decimal `json:"nav"`
+	NAVDate  Date            `json:"nav_date"`
+}
+
+// OrderRequest converts the request to the order the services place
+func (r PlaceOrderRequest) OrderRequest() v1dto.OrderRequest {
+	return v1dto.OrderRequest{
+		DataDate: model.Date(r.NAVDate.Time),
+		PortID:   r.PortID,
+		FundID:   r.FundID,
+		FundCode: r.FundCode,
+		Amount:   r.Amount,
+		Unit:     r.Units,
+		NAV:      r.NAV,
+	}
+}
+
+type Order struct {
+	ID         uint      `json:"id"`
+	Side       OrderSide `json:"side"`
+	PortID     uint      `json:"port_id"`
+	FundID     string    `json:"fund_id"`
+	FundCode   string    `json:"fund_code"`
+	CategoryID uint8     `json:"category_id"`
+	NAV        string    `json:"nav"`
+	NAVDate    *Date     `json:"nav_date"`
+	Amount     string    `json:"amount"`
+	Units      string    `json:"units"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewOrder(tran model.Transaction) Order {
+	side := OrderBuy
+	if tran.Type == model.TransactionSell {
+		side = OrderSell
+	}
+	return Order{
+		ID:         tran.ID,
+		Side:       side,
+		PortID:     tran.PortID,
+		FundID:     tran.FundID,
+		FundCode:   tran.FundCode,
+		CategoryID: tran.BcatID,
+		NAV:        nav(tran.NAV),
+		NAVDate:    dateOf(tran.DataDate),
+		Amount:     money(tran.Amount),
+		Units:      units(tran.Unit),
+		CreatedAt:  tran.CreatedAt.UTC(),
+	}
+}
+
+func NewOrders(trans []model.Transaction) []Order {
+	orders := make([]Order, 0, len(trans))
+	for _, tran := range trans {
+		orders = append(orders, NewOrder(tran))
+	}
+	return orders
+}
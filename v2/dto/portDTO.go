@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"gitlab.com/investio/backend/sim-api/v1/model"
+)
+
+type Port struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	RealizedPL string `json:"realized_pl"`
+	TotalCost  string `json:"total_cost"`
+}
+
+func NewPort(port model.Port) Port {
+	return Port{
+		ID:         port.ID,
+		Name:       port.PortName,
+		RealizedPL: money(port.ProfitLossRealized),
+		TotalCost:  money(port.AllCost),
+	}
+}
+
+// Holding is a fund held in a port
+type Holding struct {
+	FundID     string `json:"fund_id"`
+	FundCode   string `json:"fund_code"`
+	CategoryID uint8  `json:"category_id"`
+	Cost       string `json:"cost"`
+	Units      string `json:"units"`
+	RealizedPL string `json:"realized_pl"`
+}
+
+func NewHoldings(funds []model.PortFund) []Holding {
+	holdings := make([]Holding, 0, len(funds))
+	for _, fund := range funds {
+		holdings = append(holdings, Holding{
+			FundID:     fund.FundID,
+			FundCode:   fund.FundCode,
+			CategoryID: fund.BcatID,
+			Cost:       money(fund.Cost),
+			Units:      units(fund.Unit),
+			RealizedPL: money(fund.PlRealized),
+		})
+	}
+	return holdings
+}
@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"gitlab.com/investio/backend/sim-api/v1/model"
+)
+
+type Wallet struct {
+	AvailableBalance string `json:"available_balance"`
+	InOrderBalance   string `json:"in_order_balance"`
+	InAssetBalance   string `json:"in_asset_balance"`
+	TotalSpent       string `json:"total_spent"`
+	Frozen           bool   `json:"frozen"`
+}
+
+func NewWallet(wallet model.Wallet) Wallet {
+	return Wallet{
+		AvailableBalance: money(wallet.AvailableBal),
+		InOrderBalance:   money(wallet.InOrderBal),
+		InAssetBalance:   money(wallet.InAssetBal),
+		TotalSpent:       money(wallet.TotalSpend),
+		Frozen:           wallet.IsFrozen,
+	}
+}
@@ -0,0 +1,65 @@
+// Package version holds the build metadata stamped in with -ldflags, for example
+//
+//	go build -ldflags "-X gitlab.com/investio/backend/sim-api/version.Version=1.1.0 \
+//	  -X gitlab.com/investio/backend/sim-api/version.Commit=$(git rev-parse HEAD) \
+//	  -X gitlab.com/investio/backend/sim-api/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time; a plain `go build` leaves the defaults
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+	GoVersion = ""
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build metadata. Values not set through ldflags fall back to
+// what the Go toolchain recorded in the binary, when it did.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: GoVersion,
+	}
+	if info.GoVersion == "" {
+		info.GoVersion = runtime.Version()
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+	return info
+}
+
+func (i Info) String() string {
+	commit := i.Commit
+	if commit == "" {
+		commit = "unknown"
+	}
+	buildTime := i.BuildTime
+	if buildTime == "" {
+		buildTime = "unknown"
+	}
+	return fmt.Sprintf("sim-api %s (commit %s, built %s, %s)", i.Version, commit, buildTime, i.GoVersion)
+}